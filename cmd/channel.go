@@ -0,0 +1,494 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// The tabs of the channel view
+const (
+	channelNewest = iota
+	channelPopular
+	channelPlaylists
+)
+
+var channelTabs = []string{"Newest", "Popular", "Playlists"}
+
+// buildChannelView shows a channel's info above its uploads or its playlists
+func (app *App) buildChannelView() {
+	app.channel_info.SetDynamicColors(true).SetWrap(true)
+	app.channel_tabs.SetDynamicColors(true)
+
+	app.channel_videos.SetSelectable(true, false)
+	app.channel_videos.SetSelectedFunc(func(row, column int) {
+		if song := app.selectedChannelSong(); song != nil {
+			app.playQueueIndex(app.queue.InsertNext(*song))
+		}
+	})
+	app.channel_videos.SetSelectionChangedFunc(func(row, column int) {
+		// Load the next page once the last upload is reached
+		if row >= len(app.channel_songs) {
+			app.loadMoreChannelSongs("Loading more...")
+		}
+	})
+	app.channel_videos.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyDown || event.Rune() == 'j' {
+			if row, _ := app.channel_videos.GetSelection(); row >= len(app.channel_songs) {
+				app.loadMoreChannelSongs("Loading more...")
+			}
+		}
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			app.cancelOfferingPlaylist()
+			app.cancelLoadingChannelSongs()
+		case event.Key() == tcell.KeyLeft:
+			app.switchChannelTab(-1)
+		case event.Key() == tcell.KeyRight:
+			app.switchChannelTab(1)
+		case event.Rune() == 'L':
+			app.queueChannel()
+		case event.Rune() == 'u':
+			app.toggleSubscription()
+		case event.Rune() == 'S':
+			if len(app.channel_songs) > 0 {
+				app.createPlaylist("", app.channel_songs)
+			}
+		default:
+			if song := app.selectedChannelSong(); song != nil && app.handleSongKey(event, *song) {
+				return nil
+			}
+			return event
+		}
+		return nil
+	})
+
+	app.channel_playlists.SetSelectable(true, false)
+	app.channel_playlists.SetSelectedFunc(func(row, column int) {
+		if playlist, ok := app.selectedChannelPlaylist(); ok {
+			app.importPlaylist(playlist.ID)
+		}
+	})
+	app.channel_playlists.SetSelectionChangedFunc(func(row, column int) {
+		// Load the next page once the last playlist is reached
+		if row >= len(app.channel_lists)-1 {
+			app.loadMoreChannelPlaylists()
+		}
+	})
+	app.channel_playlists.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			app.cancelOfferingPlaylist()
+		case event.Key() == tcell.KeyLeft:
+			app.switchChannelTab(-1)
+		case event.Key() == tcell.KeyRight:
+			app.switchChannelTab(1)
+		case event.Rune() == 'L':
+			if playlist, ok := app.selectedChannelPlaylist(); ok {
+				app.offerPlaylist(playlist.Title, playlist.ID)
+			}
+		case event.Rune() == 'u':
+			app.toggleSubscription()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	app.channel_pages.AddPage("videos", app.channel_videos, true, true)
+	app.channel_pages.AddPage("playlists", app.channel_playlists, true, false)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(app.channel_info, 3, 0, false).
+		AddItem(app.channel_tabs, 1, 0, false).
+		AddItem(app.channel_pages, 0, 1, true)
+	app.addView("channel", "Channel", layout, nil, app.channel_pages)
+}
+
+// openChannel shows the channel that uploaded song, newest uploads first
+func (app *App) openChannel(song models.Video) {
+	app.cancelOfferingPlaylist()
+	if app.channel_cancel != nil {
+		app.channel_cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	app.channel_ctx = ctx
+	app.channel_cancel = cancel
+
+	app.channel = nil
+	app.channel_tab = channelNewest
+	app.channel_pages.SwitchToPage("videos")
+	app.views["channel"].title = "Channel: " + song.Channel
+	app.showView("channel")
+	app.channel_info.SetText(tview.Escape(fmt.Sprintf("Loading %s...", song.Channel)))
+	app.channel_tabs.SetText("")
+	app.stopLoadingChannelSongs()
+	app.channel_source++
+	app.showChannelMessage("Loading channel...")
+
+	go func() {
+		url, err := services.ResolveChannelURL(ctx, song)
+		var channel models.Channel
+		if err == nil {
+			channel, err = services.GetChannelYtDlp(ctx, url)
+		}
+
+		app.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Error loading channel: %v", err)
+				app.channel_info.SetText(tview.Escape("Error: " + err.Error()))
+				app.showChannelMessage("")
+				return
+			}
+			if channel.Name == "" {
+				channel.Name = song.Channel
+			}
+			app.channel = &channel
+			app.showChannelInfo()
+			app.showChannelTab(channelNewest)
+		})
+	}()
+}
+
+// showChannelInfo shows the name, handle, subscribers and description of
+// the open channel
+func (app *App) showChannelInfo() {
+	channel := app.channel
+	title := "Channel: " + channel.Name
+	app.views["channel"].title = title
+	if app.current_view == "channel" {
+		app.view_box.SetTitle(title)
+	}
+
+	details := []string{"[::b]" + tview.Escape(channel.Name) + "[::-]"}
+	if channel.Handle != "" {
+		details = append(details, tview.Escape(channel.Handle))
+	}
+	if channel.Subscribers > 0 {
+		details = append(details, formatCount(channel.Subscribers)+" subscribers")
+	}
+	if app.subscriptions != nil && app.subscriptions.Subscribed(channel.URL) {
+		details = append(details, "[green]subscribed[-]")
+	}
+	description, _, _ := strings.Cut(strings.TrimSpace(channel.Description), "\n")
+	app.channel_info.SetText(strings.Join(details, " · ") + "\n[gray]" + tview.Escape(description))
+}
+
+// showChannelTab switches the channel view to a tab and loads it
+func (app *App) showChannelTab(tab int) {
+	if app.channel == nil {
+		return
+	}
+	app.channel_tab = tab
+
+	var labels []string
+	for i, label := range channelTabs {
+		if i == tab {
+			label = "[yellow::b]" + label + "[-::-]"
+		}
+		labels = append(labels, label)
+	}
+	app.channel_tabs.SetText(strings.Join(labels, "  ") + "  [gray](←/→ to switch, L to queue all, u to subscribe)")
+
+	app.stopLoadingChannelSongs()
+	if tab == channelPlaylists {
+		app.channel_pages.SwitchToPage("playlists")
+		app.loadChannelPlaylists()
+	} else {
+		app.channel_pages.SwitchToPage("videos")
+		app.loadChannelSongs()
+	}
+	if app.current_view == "channel" {
+		// The pages pass the focus on to the tab that is showing
+		app.app.SetFocus(app.channel_pages)
+	}
+}
+
+// switchChannelTab moves to the next (step 1) or previous (step -1) tab
+func (app *App) switchChannelTab(step int) {
+	if app.channel == nil {
+		return
+	}
+	app.showChannelTab((app.channel_tab + step + len(channelTabs)) % len(channelTabs))
+}
+
+// queueChannel offers to play or queue all the uploads of the open channel,
+// in the order of the tab that is showing
+func (app *App) queueChannel() {
+	if app.channel == nil {
+		return
+	}
+	popular := app.channel_tab == channelPopular
+	id, err := services.ChannelPlaylistID(*app.channel, popular)
+	if !app.reportError(err) {
+		return
+	}
+	title := app.channel.Name + " uploads"
+	if popular {
+		title = app.channel.Name + " popular uploads"
+	}
+	app.offerPlaylist(title, id)
+}
+
+// offerPlaylist loads a whole YouTube playlist in the background, then asks
+// what to do with it. Esc or opening another channel stops the load.
+func (app *App) offerPlaylist(title, id string) {
+	app.cancelOfferingPlaylist()
+	ctx, cancel := context.WithCancel(app.channel_ctx)
+	app.channel_offer_cancel = cancel
+	go app.spinLoading(ctx, fmt.Sprintf("Loading %s...", title), func(text string) {
+		if app.current_view == "channel" {
+			app.view_box.SetTitle(app.views["channel"].title + " " + text)
+		}
+	})
+
+	go func() {
+		var songs []models.Video
+		err := services.GetPlaylistYtDlp(ctx, id, func(pageTitle string, videos []models.Video) {
+			songs = append(songs, videos...)
+		})
+
+		app.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			app.cancelOfferingPlaylist()
+			if !app.reportError(err) {
+				return
+			}
+			if len(songs) == 0 {
+				app.showMessage(title + " is empty")
+				return
+			}
+			app.offerSongs(title, songs, false)
+		})
+	}()
+}
+
+// loadChannelSongs lists the uploads of the open channel from the start, in
+// the order of the tab that is showing
+func (app *App) loadChannelSongs() {
+	app.channel_source++
+	app.channel_songs_more = true
+	message := fmt.Sprintf("Loading the uploads of %s...", app.channel.Name)
+	app.showChannelMessage(message)
+	app.loadMoreChannelSongs(message)
+}
+
+// loadMoreChannelSongs loads the next page of the open channel's uploads in
+// the background. Uploads are added one by one as they arrive, and message
+// is shown next to a spinner after the last one until the page is in.
+func (app *App) loadMoreChannelSongs(message string) {
+	if app.channel == nil || app.channel_songs_loading || !app.channel_songs_more {
+		return
+	}
+
+	// Opening another channel stops the load, and so does Esc
+	ctx, cancel := context.WithCancel(app.channel_ctx)
+	channel := *app.channel
+	popular := app.channel_tab == channelPopular
+	source := app.channel_source
+	start := len(app.channel_songs) + 1
+	count := app.settings.PageSize
+	app.channel_songs_loading = true
+	app.channel_songs_cancel = cancel
+	go app.spinLoading(ctx, message, func(text string) {
+		app.channel_videos.SetCell(len(app.channel_songs)+1, 0, loadingCell(text))
+	})
+
+	go func() {
+		received := 0
+		err := services.StreamChannelVideosYtDlp(ctx, channel, popular, start, count, func(song models.Video) {
+			app.app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || source != app.channel_source {
+					return
+				}
+				if len(app.channel_songs) == 0 {
+					// Replace the loading message
+					app.showChannelMessage("")
+				}
+				received++
+				app.setSongRow(app.channel_videos, len(app.channel_songs)+1, song)
+				app.channel_songs = append(app.channel_songs, song)
+			})
+		})
+
+		app.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || source != app.channel_source {
+				return
+			}
+			cancel()
+			app.channel_songs_loading = false
+			app.removeChannelLoadingRow()
+
+			if err != nil {
+				if len(app.channel_songs) == 0 {
+					app.showChannelMessage("Error: " + err.Error())
+				}
+				log.Printf("Error loading uploads: %v", err)
+				app.channel_songs_more = false
+				return
+			}
+			if len(app.channel_songs) == 0 {
+				app.showChannelMessage("No uploads")
+			}
+			// A short page means there is nothing more to load
+			app.channel_songs_more = received >= count
+		})
+	}()
+}
+
+// showChannelMessage replaces the uploads of the channel view with a
+// message, e.g. an error
+func (app *App) showChannelMessage(message string) {
+	app.channel_videos.Clear()
+	setSongTableHeader(app.channel_videos)
+	app.channel_songs = nil
+	if message != "" {
+		app.channel_videos.SetCell(1, 0, tview.NewTableCell(message))
+	}
+}
+
+// removeChannelLoadingRow removes the spinner from the end of the uploads
+func (app *App) removeChannelLoadingRow() {
+	if row := len(app.channel_songs) + 1; app.channel_videos.GetRowCount() > row {
+		app.channel_videos.RemoveRow(row)
+	}
+}
+
+// stopLoadingChannelSongs cancels the page of uploads that is being loaded,
+// if any
+func (app *App) stopLoadingChannelSongs() {
+	if app.channel_songs_cancel != nil {
+		app.channel_songs_cancel()
+		app.channel_songs_cancel = nil
+	}
+	app.channel_songs_loading = false
+}
+
+// cancelLoadingChannelSongs cancels the page of uploads that is being loaded
+// at the user's request. Scrolling to the end of the list tries again.
+func (app *App) cancelLoadingChannelSongs() {
+	if !app.channel_songs_loading {
+		return
+	}
+	app.stopLoadingChannelSongs()
+
+	if len(app.channel_songs) == 0 {
+		app.showChannelMessage("Cancelled")
+		app.channel_songs_more = false
+	} else {
+		app.removeChannelLoadingRow()
+	}
+}
+
+func (app *App) selectedChannelSong() *models.Video {
+	return tableSong(app.channel_videos)
+}
+
+// cancelOfferingPlaylist stops loading the playlist offerPlaylist is
+// loading, if any
+func (app *App) cancelOfferingPlaylist() {
+	if app.channel_offer_cancel == nil {
+		return
+	}
+	app.channel_offer_cancel()
+	app.channel_offer_cancel = nil
+	app.view_box.SetTitle(app.views[app.current_view].title)
+}
+
+// loadChannelPlaylists lists the playlists of the open channel from the start
+func (app *App) loadChannelPlaylists() {
+	app.channel_source++
+	app.channel_lists = nil
+	app.channel_lists_more = true
+	app.channel_lists_loading = false
+	app.channel_playlists.Clear()
+	app.loadMoreChannelPlaylists()
+}
+
+// loadMoreChannelPlaylists loads the next page of the open channel's
+// playlists in the background
+func (app *App) loadMoreChannelPlaylists() {
+	if app.channel == nil || app.channel_lists_loading || !app.channel_lists_more {
+		return
+	}
+	app.channel_lists_loading = true
+	channel := *app.channel
+	source := app.channel_source
+	start := len(app.channel_lists) + 1
+	count := app.settings.PageSize
+	app.channel_playlists.SetCell(start-1, 0, tview.NewTableCell("Loading playlists...").
+		SetSelectable(false).
+		SetTextColor(tcell.ColorGray))
+
+	// Opening another channel stops the load
+	ctx := app.channel_ctx
+
+	go func() {
+		var playlists []models.ChannelPlaylist
+		err := services.StreamChannelPlaylistsYtDlp(ctx, channel, start, count, func(playlist models.ChannelPlaylist) {
+			playlists = append(playlists, playlist)
+		})
+
+		app.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil || source != app.channel_source {
+				return
+			}
+			app.channel_lists_loading = false
+			app.channel_lists = append(app.channel_lists, playlists...)
+			app.channel_lists_more = err == nil && len(playlists) >= count
+			if err != nil {
+				log.Printf("Error loading playlists: %v", err)
+			}
+			app.refreshChannelPlaylists(err)
+		})
+	}()
+}
+
+// refreshChannelPlaylists redraws the playlists of the open channel
+func (app *App) refreshChannelPlaylists(err error) {
+	row, _ := app.channel_playlists.GetSelection()
+	app.channel_playlists.Clear()
+	for i, playlist := range app.channel_lists {
+		app.channel_playlists.SetCell(i, 0, tview.NewTableCell(playlist.Title).SetReference(playlist))
+	}
+
+	switch {
+	case err != nil:
+		app.channel_playlists.SetCell(len(app.channel_lists), 0, tview.NewTableCell("Error: "+err.Error()).SetSelectable(false))
+	case len(app.channel_lists) == 0:
+		app.channel_playlists.SetCell(0, 0, tview.NewTableCell("No playlists").SetSelectable(false))
+	}
+	if len(app.channel_lists) > 0 {
+		app.channel_playlists.Select(min(row, len(app.channel_lists)-1), 0)
+	}
+}
+
+func (app *App) selectedChannelPlaylist() (models.ChannelPlaylist, bool) {
+	row, _ := app.channel_playlists.GetSelection()
+	playlist, ok := app.channel_playlists.GetCell(row, 0).GetReference().(models.ChannelPlaylist)
+	return playlist, ok
+}
+
+// formatCount shortens a large number, e.g. 1.2M
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const dialogPage = "dialog"
+
+// showDialog shows p centered on top of the layout until closeDialog is called
+func (app *App) showDialog(p tview.Primitive, width, height int) {
+	app.dialog_return = app.app.GetFocus()
+
+	grid := tview.NewGrid().
+		SetColumns(0, width, 0).
+		SetRows(0, height, 0).
+		AddItem(p, 1, 1, 1, 1, 0, 0, true)
+	app.root.AddPage(dialogPage, grid, true, true)
+	app.app.SetFocus(p)
+}
+
+func (app *App) closeDialog() {
+	app.root.RemovePage(dialogPage)
+	if app.dialog_return != nil {
+		app.app.SetFocus(app.dialog_return)
+		app.dialog_return = nil
+	}
+}
+
+// dialogOpen reports whether a dialog is being shown
+func (app *App) dialogOpen() bool {
+	return app.root.HasPage(dialogPage)
+}
+
+// showPrompt asks for a line of text. done is only called if the user
+// confirms with Enter.
+func (app *App) showPrompt(title, text string, done func(text string)) {
+	input := tview.NewInputField().
+		SetText(text).
+		SetFieldBackgroundColor(tcell.ColorNone).
+		SetFieldTextColor(tcell.ColorWhite)
+	input.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
+	input.SetDoneFunc(func(key tcell.Key) {
+		app.closeDialog()
+		if key == tcell.KeyEnter {
+			done(input.GetText())
+		}
+	})
+	app.showDialog(input, 50, 3)
+}
+
+// showConfirm asks a yes/no question and calls done if the answer is yes
+func (app *App) showConfirm(text string, done func()) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(index int, label string) {
+			app.closeDialog()
+			if label == "Yes" {
+				done()
+			}
+		})
+	app.showModal(modal)
+}
+
+// showMessage shows a message until the user dismisses it
+func (app *App) showMessage(text string) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(index int, label string) {
+			app.closeDialog()
+		})
+	app.showModal(modal)
+}
+
+// showModal shows a tview.Modal, which centers itself
+func (app *App) showModal(modal *tview.Modal) {
+	app.dialog_return = app.app.GetFocus()
+	app.root.AddPage(dialogPage, modal, false, true)
+	app.app.SetFocus(modal)
+}
+
+// showChoice lets the user pick one of options. done is called with the
+// index of the chosen option, Esc cancels.
+func (app *App) showChoice(title string, options []string, done func(index int)) {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
+	for i, option := range options {
+		index := i
+		list.AddItem(option, "", 0, func() {
+			app.closeDialog()
+			done(index)
+		})
+	}
+	list.SetDoneFunc(app.closeDialog)
+	app.showDialog(list, 50, min(len(options)+2, 20))
+}
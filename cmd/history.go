@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// buildHistoryView lists the tracks that were played, newest first
+func (app *App) buildHistoryView() {
+	app.history_list.SetSelectable(true, false)
+	app.history_list.SetSelectedFunc(func(row, column int) {
+		if entry, ok := app.selectedHistoryEntry(); ok {
+			app.playQueueIndex(app.queue.InsertNext(entry.Video))
+		}
+	})
+	app.history_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'E':
+			app.exportHistoryFile()
+			return nil
+		case 'C':
+			if app.history != nil {
+				app.showConfirm("Clear the play history?", func() {
+					app.reportError(app.history.Clear())
+					app.refreshHistory()
+				})
+			}
+			return nil
+		}
+
+		entry, ok := app.selectedHistoryEntry()
+		if !ok {
+			return event
+		}
+		if app.handleTrackKey(event, entry.Video) {
+			return nil
+		}
+		switch event.Rune() {
+		case 'a':
+			app.enqueue(entry.Video, false)
+		case 'A':
+			app.enqueue(entry.Video, true)
+		case 'P':
+			app.addToPlaylist(entry.Video)
+		default:
+			return event
+		}
+		return nil
+	})
+
+	app.addView("history", "History", app.history_list, app.refreshHistory, app.history_list)
+}
+
+// refreshHistory redraws the history, newest first
+func (app *App) refreshHistory() {
+	row, _ := app.history_list.GetSelection()
+	app.history_list.Clear()
+	headers := []string{"Played", "Title", "Channel", "Listened", ""}
+	for i, header := range headers {
+		app.history_list.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.history_list.SetFixed(1, 0)
+
+	if app.history == nil {
+		return
+	}
+	entries := app.history.Entries()
+	for i := range entries {
+		entry := entries[len(entries)-1-i]
+		color := tcell.ColorWhite
+		if entry.Outcome != models.HistoryFinished {
+			color = tcell.ColorGray
+		}
+		app.history_list.SetCell(i+1, 0, tview.NewTableCell(entry.StartedAt.Format("2006-01-02 15:04")).
+			SetReference(entry).
+			SetTextColor(color))
+		app.history_list.SetCell(i+1, 1, tview.NewTableCell(entry.Video.Title).SetMaxWidth(30).SetTextColor(color))
+		app.history_list.SetCell(i+1, 2, tview.NewTableCell(entry.Video.Channel).SetMaxWidth(15).SetTextColor(color))
+		app.history_list.SetCell(i+1, 3, tview.NewTableCell(services.FormatDuration(entry.Listened)).SetTextColor(color))
+		app.history_list.SetCell(i+1, 4, tview.NewTableCell(historyOutcome(entry.Outcome)).SetTextColor(color))
+	}
+	if len(entries) > 0 {
+		app.history_list.Select(max(1, min(row, len(entries))), 0)
+	}
+}
+
+func historyOutcome(outcome models.HistoryOutcome) string {
+	if outcome == models.HistoryPlaying {
+		return "playing"
+	}
+	return string(outcome)
+}
+
+func (app *App) selectedHistoryEntry() (models.HistoryEntry, bool) {
+	row, _ := app.history_list.GetSelection()
+	if row <= 0 {
+		return models.HistoryEntry{}, false
+	}
+	entry, ok := app.history_list.GetCell(row, 0).GetReference().(models.HistoryEntry)
+	return entry, ok
+}
+
+// startHistory opens a history entry for the song that has just started
+func (app *App) startHistory(song models.Video) {
+	app.listened = 0
+	app.listening = false
+	if app.history == nil {
+		return
+	}
+	if err := app.history.Start(song); err != nil {
+		log.Printf("Error saving history: %v", err)
+	}
+	if app.current_view == "history" {
+		app.refreshHistory()
+	}
+}
+
+// finishHistory closes the history entry of the current song
+func (app *App) finishHistory(outcome models.HistoryOutcome) {
+	if app.history == nil {
+		return
+	}
+	if err := app.history.Finish(app.listenedTime(), outcome); err != nil {
+		log.Printf("Error saving history: %v", err)
+	}
+	if app.current_view == "history" {
+		app.refreshHistory()
+	}
+}
+
+// trackListening adds up the time the current song spends playing. Events
+// from a song that has been replaced arrive after startHistory has reset
+// the count and are ignored.
+func (app *App) trackListening(event services.PlayerEvent) {
+	if event.State == services.PlayerPlaying && !app.listening {
+		app.listening = true
+		app.listen_start = time.Now()
+	} else if event.From == services.PlayerPlaying && app.listening {
+		app.listening = false
+		app.listened += time.Since(app.listen_start)
+	}
+}
+
+// listenedTime returns how long the current song has been playing for
+func (app *App) listenedTime() time.Duration {
+	if app.listening {
+		return app.listened + time.Since(app.listen_start)
+	}
+	return app.listened
+}
+
+// exportHistoryFile asks for a file name and writes the history to it
+func (app *App) exportHistoryFile() {
+	if app.history == nil {
+		return
+	}
+	app.showPrompt("Export history to (.csv or .json)", "history.csv", func(path string) {
+		path = expandPath(path)
+		entries := app.history.Entries()
+		if app.reportError(services.ExportHistory(path, entries)) {
+			app.showMessage(fmt.Sprintf("Exported %d plays to %s", len(entries), path))
+		}
+	})
+}
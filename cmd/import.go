@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// importPlaylist lists a YouTube playlist or mix in the music view, page by
+// page, then asks what to do with it
+func (app *App) importPlaylist(id string) {
+	app.loadPlaylist(id, true)
+}
+
+// loadPlaylist lists a YouTube playlist or mix in the music view, asking
+// what to do with it once it has loaded if offer is set
+func (app *App) loadPlaylist(id string, offer bool) {
+	app.showView("music")
+	app.setMusicSource(nil)
+	app.music_playlist = id
+	app.showMusicMessage("Loading playlist...")
+	app.view_box.SetTitle("Playlist")
+
+	// Esc or loading something else into the list stops the load
+	ctx, cancel := context.WithCancel(context.Background())
+	source := app.music_source
+	app.music_loading = true
+	app.music_cancel = cancel
+	go app.spinMusicLoading(ctx, "Loading playlist...")
+
+	go func() {
+		title := id
+		first := true
+		err := services.GetPlaylistYtDlp(ctx, id, func(pageTitle string, videos []models.Video) {
+			app.app.QueueUpdateDraw(func() {
+				if ctx.Err() != nil || source != app.music_source {
+					return
+				}
+				if first {
+					app.showSongs(nil)
+					first = false
+				}
+				if pageTitle != "" {
+					title = pageTitle
+				}
+				app.appendSongs(videos)
+				app.view_box.SetTitle(fmt.Sprintf("Playlist: %s (%d tracks, loading...)", title, len(app.music_songs)))
+			})
+		})
+
+		app.app.QueueUpdateDraw(func() {
+			if source != app.music_source {
+				return
+			}
+			if ctx.Err() != nil {
+				// Cancelled, the tracks listed so far stay
+				if len(app.music_songs) > 0 {
+					app.view_box.SetTitle(fmt.Sprintf("Playlist: %s (%d tracks)", title, len(app.music_songs)))
+				}
+				return
+			}
+			cancel()
+			app.music_loading = false
+			app.removeMusicLoadingRow()
+
+			if err != nil {
+				app.showMusicMessage("Error: " + err.Error())
+				return
+			}
+			if len(app.music_songs) == 0 {
+				app.showMusicMessage("The playlist is empty")
+				return
+			}
+
+			app.view_box.SetTitle(fmt.Sprintf("Playlist: %s (%d tracks)", title, len(app.music_songs)))
+			if !offer {
+				return
+			}
+			app.offerSongs(title, append([]models.Video(nil), app.music_songs...), true)
+		})
+	}()
+}
+
+// offerSongs asks whether to play, queue or save songs that were just imported
+func (app *App) offerSongs(title string, songs []models.Video, browse bool) {
+	options := []string{"Play now", "Add to queue", "Save as local playlist"}
+	if browse {
+		options = append(options, "Browse")
+	}
+
+	app.showChoice(tview.Escape(fmt.Sprintf("%s (%d tracks)", title, len(songs))), options, func(index int) {
+		switch index {
+		case 0:
+			app.queue.Clear()
+			for _, song := range songs {
+				app.queue.Append(song)
+			}
+			app.playQueueIndex(0)
+		case 1:
+			for _, song := range songs {
+				app.queue.Append(song)
+			}
+			app.refreshQueue()
+		case 2:
+			app.createPlaylist(title, songs)
+		}
+	})
+}
+
+// importPlaylistFile reads an M3U, XSPF or JSON playlist from disk
+func (app *App) importPlaylistFile() {
+	app.showPrompt("Import playlist file", "", func(path string) {
+		path = expandPath(path)
+		go func() {
+			playlist, err := services.ImportPlaylist(path)
+			app.app.QueueUpdateDraw(func() {
+				if !app.reportError(err) {
+					return
+				}
+				if len(playlist.Entries) == 0 {
+					app.showMessage("No YouTube videos found in " + path)
+					return
+				}
+				app.offerSongs(playlist.Name, playlist.Videos(), false)
+			})
+		}()
+	})
+}
+
+// exportPlaylistFile asks for a file name and writes playlist to it. The
+// extension picks the format.
+func (app *App) exportPlaylistFile(playlist models.Playlist) {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, playlist.Name)
+
+	app.showPrompt("Export to (.m3u, .xspf or .json)", name+".m3u", func(path string) {
+		path = expandPath(path)
+		if app.reportError(services.ExportPlaylist(path, playlist)) {
+			app.showMessage(fmt.Sprintf("Exported %d tracks to %s", len(playlist.Entries), path))
+		}
+	})
+}
+
+// queuePlaylist returns the queue as a playlist that can be exported
+func (app *App) queuePlaylist() models.Playlist {
+	now := time.Now()
+	playlist := models.Playlist{Name: "Queue", CreatedAt: now}
+	for _, video := range app.queue.Items() {
+		playlist.Entries = append(playlist.Entries, models.PlaylistEntry{Video: video, AddedAt: now})
+	}
+	return playlist
+}
+
+// expandPath expands a leading ~ to the home directory
+func expandPath(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// buildFavoritesView lists the tracks that are favorites, rated, tagged or
+// have notes, filtered by tag
+func (app *App) buildFavoritesView() {
+	app.favorites_filter.
+		SetLabel("Tags: ").
+		SetPlaceholder("e.g. chill work").
+		SetFieldBackgroundColor(tcell.ColorNone).
+		SetFieldTextColor(tcell.ColorWhite)
+	app.favorites_filter.SetChangedFunc(func(text string) {
+		app.refreshFavorites()
+	})
+	app.favorites_filter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			app.app.SetFocus(app.favorites_list)
+		}
+	})
+
+	app.favorites_list.SetSelectable(true, false)
+	app.favorites_list.SetSelectedFunc(func(row, column int) {
+		if song, ok := app.selectedFavorite(); ok {
+			app.playQueueIndex(app.queue.InsertNext(song))
+		}
+	})
+	app.favorites_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'L':
+			// Queue every track that matches the filter
+			for _, song := range app.favoriteSongs() {
+				app.queue.Append(song)
+			}
+			app.refreshQueue()
+			return nil
+		case 'S':
+			if songs := app.favoriteSongs(); len(songs) > 0 {
+				app.createPlaylist(strings.Join(app.favoriteTags(), " "), songs)
+			}
+			return nil
+		}
+
+		song, ok := app.selectedFavorite()
+		if !ok {
+			return event
+		}
+		if app.handleTrackKey(event, song) {
+			return nil
+		}
+		switch event.Rune() {
+		case 'a':
+			app.enqueue(song, false)
+		case 'A':
+			app.enqueue(song, true)
+		case 'P':
+			app.addToPlaylist(song)
+		default:
+			return event
+		}
+		return nil
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(app.favorites_filter, 1, 0, false).
+		AddItem(app.favorites_list, 0, 1, true)
+	app.addView("favorites", "Favorites", layout, app.refreshFavorites, app.favorites_list, app.favorites_filter)
+}
+
+// refreshFavorites redraws the tracks that match the tag filter
+func (app *App) refreshFavorites() {
+	row, _ := app.favorites_list.GetSelection()
+	app.favorites_list.Clear()
+	headers := []string{"Rating", "Title", "Channel", "Duration", "Tags", "Notes"}
+	for i, header := range headers {
+		app.favorites_list.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.favorites_list.SetFixed(1, 0)
+
+	if app.library == nil {
+		return
+	}
+	tracks := app.library.Tracks(app.favoriteTags()...)
+	for i, track := range tracks {
+		app.favorites_list.SetCell(i+1, 0, tview.NewTableCell(formatRating(track)).
+			SetReference(track.Video).
+			SetTextColor(tcell.ColorYellow))
+		app.favorites_list.SetCell(i+1, 1, tview.NewTableCell(track.Video.Title).SetMaxWidth(30))
+		app.favorites_list.SetCell(i+1, 2, tview.NewTableCell(track.Video.Channel).SetMaxWidth(15))
+		app.favorites_list.SetCell(i+1, 3, tview.NewTableCell(formatTotal(services.ParseDuration(track.Video.Duration))))
+		app.favorites_list.SetCell(i+1, 4, tview.NewTableCell(strings.Join(track.Tags, " ")).SetMaxWidth(20))
+		app.favorites_list.SetCell(i+1, 5, tview.NewTableCell(track.Notes).SetMaxWidth(20))
+	}
+	if len(tracks) > 0 {
+		app.favorites_list.Select(max(1, min(row, len(tracks))), 0)
+	}
+	app.view_box.SetTitle(fmt.Sprintf("Favorites (%d tracks)", len(tracks)))
+}
+
+// favoriteTags returns the tags typed into the filter of the Favorites view
+func (app *App) favoriteTags() []string {
+	return services.NormalizeTags([]string{app.favorites_filter.GetText()})
+}
+
+// favoriteSongs returns the songs listed in the Favorites view
+func (app *App) favoriteSongs() []models.Video {
+	var songs []models.Video
+	for row := 1; row < app.favorites_list.GetRowCount(); row++ {
+		if song, ok := app.favorites_list.GetCell(row, 0).GetReference().(models.Video); ok {
+			songs = append(songs, song)
+		}
+	}
+	return songs
+}
+
+func (app *App) selectedFavorite() (models.Video, bool) {
+	row, _ := app.favorites_list.GetSelection()
+	if row <= 0 {
+		return models.Video{}, false
+	}
+	song, ok := app.favorites_list.GetCell(row, 0).GetReference().(models.Video)
+	return song, ok
+}
+
+// handleTrackKey handles the keys that favorite, rate, tag or annotate a
+// song, or open its channel. It returns false for any other key.
+func (app *App) handleTrackKey(event *tcell.EventKey, song models.Video) bool {
+	if event.Rune() == 'c' {
+		app.openChannel(song)
+		return true
+	}
+	if app.library == nil {
+		return false
+	}
+
+	switch event.Rune() {
+	case 'f':
+		_, err := app.library.ToggleFavorite(song)
+		app.reportError(err)
+		app.refreshLibrary()
+	case '*':
+		options := []string{"★", "★★", "★★★", "★★★★", "★★★★★", "No rating"}
+		app.showChoice("Rate "+tview.Escape(song.Title), options, func(index int) {
+			app.reportError(app.library.SetRating(song, (index+1)%len(options)))
+			app.refreshLibrary()
+		})
+	case 't':
+		tags := strings.Join(app.library.Get(song.ID).Tags, " ")
+		app.showPrompt("Tags (separated by spaces)", tags, func(text string) {
+			app.reportError(app.library.SetTags(song, []string{text}))
+			app.refreshLibrary()
+		})
+	case 'N':
+		app.showPrompt("Notes", app.library.Get(song.ID).Notes, func(text string) {
+			app.reportError(app.library.SetNotes(song, text))
+			app.refreshLibrary()
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// refreshLibrary redraws the ratings after a track has been favorited,
+// rated, tagged or annotated
+func (app *App) refreshLibrary() {
+	for _, table := range []*tview.Table{app.music_list, app.channel_videos} {
+		for row := 1; row < table.GetRowCount(); row++ {
+			if song, ok := table.GetCell(row, 0).GetReference().(*models.Video); ok {
+				table.SetCell(row, 3, app.ratingCell(*song))
+			}
+		}
+	}
+	if app.current_view == "favorites" {
+		app.refreshFavorites()
+	}
+}
+
+// ratingCell shows whether a song is a favorite and its rating
+func (app *App) ratingCell(song models.Video) *tview.TableCell {
+	var track models.TrackInfo
+	if app.library != nil {
+		track = app.library.Get(song.ID)
+	}
+	return tview.NewTableCell(formatRating(track)).SetTextColor(tcell.ColorYellow)
+}
+
+func formatRating(track models.TrackInfo) string {
+	text := strings.Repeat("★", track.Rating)
+	if track.Favorite {
+		text = "♥ " + text
+	}
+	return text
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -14,123 +14,515 @@ import (
 	"github.com/rivo/tview"
 	"github.com/sangnt1552314/ytview/internal/models"
 	"github.com/sangnt1552314/ytview/internal/services"
+	"github.com/sangnt1552314/ytview/internal/widgets"
 )
 
+const (
+	radioLowWater = 2  // tracks left in the queue before the radio adds more
+	radioBatch    = 10 // tracks the radio adds at a time
+)
+
+// musicPage loads count songs, starting at the 1-based position start, and
+// passes each one to onSong as soon as it arrives
+type musicPage func(ctx context.Context, start, count int, onSong func(models.Video)) error
+
 type App struct {
-	app            *tview.Application
-	music_list     *tview.Table
-	playing_song   *models.Video
-	playing_url    string
-	playing_box    *tview.TextView
-	control_button *tview.Button
-	timer          *time.Timer
-	start_time     time.Time
-	duration       time.Duration
-	elapsed        time.Duration
-}
-
-func NewApp() *App {
+	app                   *tview.Application
+	root                  *tview.Pages
+	pages                 *tview.Pages
+	view_box              *tview.Flex
+	views                 map[string]*view
+	current_view          string
+	dialog_return         tview.Primitive
+	local_keys            map[tview.Primitive]string // keys a primitive handles itself instead of the global shortcuts
+	search_box            *tview.InputField
+	search_recall         int    // index of the past search in the search box, -1 if none
+	search_draft          string // what was typed before walking the search history
+	suggester             *services.Suggester
+	suggest_open          bool // the search box suggestions are showing
+	searches              *services.SearchStore
+	saved_list            *tview.Table
+	menu                  *tview.List
+	player                services.Player
+	settings              models.Settings
+	music_list            *tview.Table
+	music_songs           []models.Video
+	music_page            musicPage // loads more songs into the music list
+	music_source          int       // changes whenever music_page does
+	music_offset          int       // position of the last song loaded from music_page, before filtering
+	music_filtered        bool
+	music_query           string          // search the music list is showing, if any
+	music_saved           string          // saved search the music list is showing, if any
+	music_playlist        string          // YouTube playlist the music list is showing, if any
+	music_seen            map[string]bool // songs the saved search has shown before
+	music_more            bool
+	music_loading         bool
+	music_cancel          context.CancelFunc
+	queue                 *services.Queue
+	radio                 *services.Radio
+	radio_loading         bool
+	queue_list            *tview.Table
+	playlist_box          *tview.Flex
+	playlists             *services.PlaylistStore
+	playlist_names        *tview.Table
+	playlist_entries      *tview.Table
+	history               *services.HistoryStore
+	history_list          *tview.Table
+	library               *services.LibraryStore
+	favorites_filter      *tview.InputField
+	favorites_list        *tview.Table
+	channel               *models.Channel // channel shown in the channel view
+	channel_tab           int
+	channel_info          *tview.TextView
+	channel_tabs          *tview.TextView
+	channel_pages         *tview.Pages
+	channel_videos        *tview.Table
+	channel_songs         []models.Video
+	channel_songs_more    bool
+	channel_songs_loading bool
+	channel_songs_cancel  context.CancelFunc
+	channel_playlists     *tview.Table
+	channel_lists         []models.ChannelPlaylist
+	channel_lists_more    bool
+	channel_lists_loading bool
+	channel_source        int // changes whenever the uploads or playlists are reloaded
+	channel_ctx           context.Context
+	channel_cancel        context.CancelFunc
+	channel_offer_cancel  context.CancelFunc // stops the playlist offerPlaylist is loading
+	subscriptions         *services.SubscriptionStore
+	subscriptions_list    *tview.Table
+	subscriptions_item    int // index of Subscriptions in the menu
+	subscriptions_loading bool
+	subscriptions_pending bool // check again once the running check is done
+	subscriptions_ctx     context.Context
+	subscriptions_cancel  context.CancelFunc
+	listened              time.Duration // time the current song has spent playing
+	listen_start          time.Time
+	listening             bool
+	playing_song          *models.Video
+	playing_url           string
+	playing_box           *tview.TextView
+	control_button        *tview.Button
+	volume_box            *tview.TextView
+	mode_box              *tview.TextView
+	progress_bar          *widgets.ProgressBar
+	timer                 *time.Ticker
+	timer_done            chan struct{}
+	start_time            time.Time
+	duration              time.Duration
+	elapsed               time.Duration
+	position              time.Duration // last position reported by the player
+	position_known        bool
+	resume_position       time.Duration // position to seek to once the track starts
+}
+
+func NewApp(player services.Player, settings models.Settings) *App {
 	button := tview.NewButton("▶️ Play")
 	button.SetActivatedStyle(tcell.Style{}.Background(tcell.ColorBlack))
 	button.SetStyle(tcell.Style{}.Background(tcell.ColorBlack))
 
 	return &App{
-		app:            tview.NewApplication(),
-		music_list:     tview.NewTable(),
-		playing_box:    tview.NewTextView().SetTextAlign(tview.AlignCenter),
-		control_button: button,
+		app:                tview.NewApplication(),
+		root:               tview.NewPages(),
+		pages:              tview.NewPages(),
+		view_box:           tview.NewFlex(),
+		views:              make(map[string]*view),
+		local_keys:         make(map[tview.Primitive]string),
+		search_box:         tview.NewInputField(),
+		search_recall:      -1,
+		saved_list:         tview.NewTable(),
+		menu:               tview.NewList(),
+		player:             player,
+		settings:           settings,
+		music_list:         tview.NewTable(),
+		queue:              services.NewQueue(),
+		radio:              services.NewRadio(),
+		queue_list:         tview.NewTable(),
+		playlist_box:       tview.NewFlex(),
+		playlist_names:     tview.NewTable(),
+		playlist_entries:   tview.NewTable(),
+		history_list:       tview.NewTable(),
+		favorites_filter:   tview.NewInputField(),
+		favorites_list:     tview.NewTable(),
+		channel_info:       tview.NewTextView(),
+		channel_tabs:       tview.NewTextView(),
+		channel_pages:      tview.NewPages(),
+		channel_videos:     tview.NewTable(),
+		channel_playlists:  tview.NewTable(),
+		subscriptions_list: tview.NewTable(),
+		playing_box:        tview.NewTextView().SetTextAlign(tview.AlignCenter),
+		control_button:     button,
+		volume_box:         tview.NewTextView().SetTextAlign(tview.AlignCenter),
+		mode_box:           tview.NewTextView().SetTextAlign(tview.AlignCenter),
+		progress_bar:       widgets.NewProgressBar(),
 	}
 }
 
-func (app *App) setMusicTableHeader() {
+// setSongTableHeader sets the header row of a table listing songs
+func setSongTableHeader(table *tview.Table) {
 	// Set headers with styling
-	app.music_list.SetCell(0, 0, tview.NewTableCell("Title").
+	table.SetCell(0, 0, tview.NewTableCell("Title").
 		SetMaxWidth(18).SetSelectable(false).
 		SetTextColor(tcell.ColorYellow).
 		SetAttributes(tcell.AttrBold))
-	app.music_list.SetCell(0, 1, tview.NewTableCell("Channel").
+	table.SetCell(0, 1, tview.NewTableCell("Channel").
 		SetMaxWidth(9).SetSelectable(false).
 		SetTextColor(tcell.ColorYellow).
 		SetAttributes(tcell.AttrBold))
-	app.music_list.SetCell(0, 2, tview.NewTableCell("Duration").
+	table.SetCell(0, 2, tview.NewTableCell("Duration").
 		SetMaxWidth(5).
 		SetSelectable(false).
 		SetTextColor(tcell.ColorYellow).
 		SetAttributes(tcell.AttrBold))
+	table.SetCell(0, 3, tview.NewTableCell("Rating").
+		SetSelectable(false).
+		SetTextColor(tcell.ColorYellow).
+		SetAttributes(tcell.AttrBold))
 
 	// Fix header row
-	app.music_list.SetFixed(1, 0)
+	table.SetFixed(1, 0)
+}
+
+// setSongRow shows song in a row of a table listing songs and returns the
+// title cell
+func (app *App) setSongRow(table *tview.Table, row int, song models.Video) *tview.TableCell {
+	titleCell := tview.NewTableCell(song.Title).SetReference(&song)
+	table.SetCell(row, 0, titleCell)
+	table.SetCell(row, 1, tview.NewTableCell(song.Channel))
+	table.SetCell(row, 2, tview.NewTableCell(formatTotal(services.ParseDuration(song.Duration))))
+	table.SetCell(row, 3, app.ratingCell(song))
+	return titleCell
+}
+
+// tableSong returns the song in the selected row of a table listing songs
+func tableSong(table *tview.Table) *models.Video {
+	row, _ := table.GetSelection()
+	if row <= 0 {
+		return nil
+	}
+	video, _ := table.GetCell(row, 0).GetReference().(*models.Video)
+	return video
 }
 
-func (app *App) performSearch(query string, maxResults int) {
+// showSongs replaces the rows of the music list with songs
+func (app *App) showSongs(songs []models.Video) {
 	app.music_list.Clear()
-	app.setMusicTableHeader()
+	setSongTableHeader(app.music_list)
+	app.music_songs = nil
+	app.appendSongs(songs)
+}
 
-	songs, err := services.GetSongListYtDlp(query, maxResults)
+// appendSongs adds rows to the end of the music list
+func (app *App) appendSongs(songs []models.Video) {
+	for _, song := range songs {
+		titleCell := app.setSongRow(app.music_list, len(app.music_songs)+1, song)
+		if app.music_seen != nil && !app.music_seen[song.ID] {
+			// New since the saved search was last run
+			titleCell.SetText("🆕 " + song.Title).SetTextColor(tcell.ColorLightGreen)
+		}
+		app.music_songs = append(app.music_songs, song)
+	}
+	app.markSeen(songs)
+}
+
+// showMusicMessage replaces the music list with a message, e.g. an error
+func (app *App) showMusicMessage(message string) {
+	app.music_list.Clear()
+	setSongTableHeader(app.music_list)
+	app.music_songs = nil
+	app.music_list.SetCell(1, 0, tview.NewTableCell(message))
+}
+
+// submitSearch acts on text entered into the search box: a playlist is
+// imported, anything else is searched for
+func (app *App) submitSearch(text string) {
+	if id, ok := services.ParsePlaylistID(text); ok {
+		app.importPlaylist(id)
+		app.app.SetFocus(app.music_list)
+	} else if text != "" {
+		app.recordSearch(text)
+		app.showView("music")
+		app.performSearch(text)
+		app.app.SetFocus(app.music_list) // Focus directly on the table for navigation
+	}
+}
 
+// performSearch searches for text, which may hold filters such as
+// duration:<10m on top of the ones set in the filter form
+func (app *App) performSearch(text string) {
+	query, filters, err := services.ParseSearchQuery(text, app.settings.SearchFilters)
+	if err == nil && query == "" {
+		err = fmt.Errorf("nothing to search for")
+	}
 	if err != nil {
-		app.music_list.SetCell(1, 0, tview.NewTableCell("Error: "+err.Error()))
+		app.setMusicSource(nil)
+		app.showMusicMessage("Error: " + err.Error())
 		return
 	}
 
-	for i, song := range songs {
-		duration := formatDuration(parseDuration(song.Duration))
-		titleCell := tview.NewTableCell(song.Title).SetReference(&song)
-
-		app.music_list.SetCell(i+1, 0, titleCell)
-		app.music_list.SetCell(i+1, 1, tview.NewTableCell(song.Channel))
-		app.music_list.SetCell(i+1, 2, tview.NewTableCell(duration)) // Use formatted duration
+	message := fmt.Sprintf("Searching for %q...", query)
+	if !filters.Empty() {
+		message = fmt.Sprintf("Searching for %q with filters...", query)
 	}
+	app.loadSongs(message, !filters.Empty(), func(ctx context.Context, start, count int, onSong func(models.Video)) error {
+		return services.StreamSongPageYtDlp(ctx, query, filters, start, count, onSong)
+	})
+	app.music_query = text
+}
+
+func (app *App) initMusicData() {
+	app.loadSongs("Loading trending songs...", false, services.StreamTrendingSongPageYtDlp)
 }
 
-func (app *App) initMusicData(maxResults int) {
-	// Show loading message
-	app.music_list.SetCell(1, 0, tview.NewTableCell("Loading trending songs..."))
+// loadSongs fills the music list from page, one page at a time: the first
+// one straight away and the next ones as the list is scrolled to the end.
+// filtered tells that page leaves some songs out, so a short page doesn't
+// mean that there are no more.
+func (app *App) loadSongs(message string, filtered bool, page musicPage) {
+	app.setMusicSource(page)
+	app.music_filtered = filtered
+	app.showMusicMessage(message)
+	app.loadMoreSongs(message)
+}
+
+// setMusicSource sets where loadMoreSongs gets songs from, nil for nowhere.
+// A page still being loaded from the previous source is cancelled.
+func (app *App) setMusicSource(page musicPage) {
+	app.stopLoadingSongs()
+	app.music_source++
+	app.music_page = page
+	app.music_more = page != nil
+	app.music_filtered = false
+	app.music_offset = 0
+	app.music_query = ""
+	app.music_saved = ""
+	app.music_playlist = ""
+	app.music_seen = nil
+}
+
+// loadMoreSongs loads the next page of songs into the music list in the
+// background, keeping the selection. Songs are added one by one as they
+// arrive, and message is shown next to a spinner after the last one until
+// the whole page is in.
+func (app *App) loadMoreSongs(message string) {
+	if app.music_page == nil || app.music_loading || !app.music_more {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := app.music_source
+	page := app.music_page
+	start := app.music_offset + 1
+	count := app.settings.PageSize
+	app.music_loading = true
+	app.music_cancel = cancel
+	go app.spinMusicLoading(ctx, message)
 
-	// Run the data fetching in a goroutine
 	go func() {
-		songs, err := services.GetTrendingSongListYtDlp(maxResults)
+		received := 0
+		err := page(ctx, start, count, func(song models.Video) {
+			app.app.QueueUpdateDraw(func() {
+				// Cancelled loads have been cleaned up already, and a new
+				// source cancels the loads of the old one
+				if ctx.Err() != nil || source != app.music_source {
+					return
+				}
+				if len(app.music_songs) == 0 {
+					// Replace the loading message
+					app.showSongs(nil)
+				}
+				received++
+				app.appendSongs([]models.Video{song})
+			})
+		})
 
-		// Use QueueUpdateDraw to safely update UI from goroutine
 		app.app.QueueUpdateDraw(func() {
-			app.music_list.Clear()
-			app.setMusicTableHeader()
+			if ctx.Err() != nil || source != app.music_source {
+				return
+			}
+			cancel()
+			app.music_loading = false
+			app.removeMusicLoadingRow()
 
 			if err != nil {
-				app.music_list.SetCell(1, 0, tview.NewTableCell("Error: "+err.Error()))
+				if len(app.music_songs) == 0 {
+					app.showMusicMessage("Error: " + err.Error())
+				}
+				log.Printf("Error loading songs: %v", err)
+				app.music_more = false
 				return
 			}
+			app.music_offset += count
+			if len(app.music_songs) == 0 {
+				if app.music_filtered {
+					app.showMusicMessage(fmt.Sprintf("None of the first %d results match the filters, press Down to look further", app.music_offset))
+				} else {
+					app.showMusicMessage("No results")
+				}
+			}
+			// A short page means there is nothing more to load, unless
+			// songs were filtered out of it
+			app.music_more = received >= count || app.music_filtered
+		})
+	}()
+}
 
-			for i, song := range songs {
-				duration := formatDuration(parseDuration(song.Duration))
-				titleCell := tview.NewTableCell(song.Title).SetReference(&song)
+// spinMusicLoading animates a spinner next to message in the row after the
+// last song until ctx is done
+func (app *App) spinMusicLoading(ctx context.Context, message string) {
+	app.spinLoading(ctx, message, func(text string) {
+		app.music_list.SetCell(len(app.music_songs)+1, 0, loadingCell(text))
+	})
+}
+
+// spinLoading animates a spinner next to message until ctx is done. draw
+// shows the text on the event loop.
+func (app *App) spinLoading(ctx context.Context, message string, draw func(text string)) {
+	frames := []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 
-				app.music_list.SetCell(i+1, 0, titleCell)
-				app.music_list.SetCell(i+1, 1, tview.NewTableCell(song.Channel))
-				app.music_list.SetCell(i+1, 2, tview.NewTableCell(duration))
+	for frame := 0; ; frame++ {
+		text := fmt.Sprintf("%c %s (Esc to cancel)", frames[frame%len(frames)], message)
+		app.app.QueueUpdateDraw(func() {
+			if ctx.Err() == nil {
+				draw(text)
 			}
 		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// loadingCell is the cell of a table row saying that more is being loaded
+func loadingCell(text string) *tview.TableCell {
+	return tview.NewTableCell(tview.Escape(text)).
+		SetSelectable(false).
+		SetTextColor(tcell.ColorGray)
+}
+
+// removeMusicLoadingRow removes the spinner from the end of the music list
+func (app *App) removeMusicLoadingRow() {
+	if row := len(app.music_songs) + 1; app.music_list.GetRowCount() > row {
+		app.music_list.RemoveRow(row)
+	}
+}
+
+// stopLoadingSongs cancels the page that is being loaded, if any
+func (app *App) stopLoadingSongs() {
+	if app.music_cancel != nil {
+		app.music_cancel()
+		app.music_cancel = nil
+	}
+	app.music_loading = false
+}
+
+// cancelLoadingSongs cancels the page that is being loaded at the user's
+// request. Scrolling to the end of the list tries again.
+func (app *App) cancelLoadingSongs() {
+	if !app.music_loading {
+		return
+	}
+	app.stopLoadingSongs()
+
+	if len(app.music_songs) == 0 {
+		app.showMusicMessage("Cancelled")
+		app.music_more = false
+	} else {
+		app.removeMusicLoadingRow()
+	}
+}
+
+// playerState returns the state of the player, treating a missing player as idle
+func (app *App) playerState() services.PlayerState {
+	if app.player == nil {
+		return services.PlayerIdle
+	}
+	return app.player.State()
+}
+
+// watchPlayer applies the player's state changes to the UI as they happen
+func (app *App) watchPlayer() {
+	if app.player == nil {
+		return
+	}
+	go func() {
+		for event := range app.player.Events() {
+			app.app.QueueUpdateDraw(func() {
+				app.handlePlayerEvent(event)
+			})
+		}
 	}()
 }
 
+func (app *App) handlePlayerEvent(event services.PlayerEvent) {
+	app.trackListening(event)
+	if event.State == services.PlayerPlaying && app.resume_position > 0 {
+		position := app.resume_position
+		app.resume_position = 0
+		app.seekTo(position)
+	}
+	if event.State == services.PlayerEnded {
+		app.finishHistory(models.HistoryFinished)
+		if song, ok := app.queue.Advance(); ok {
+			app.playQueued(song)
+		} else {
+			// The queue has run dry, let the radio pick what comes next
+			app.fillRadio(true)
+		}
+	}
+	if event.State == services.PlayerError {
+		log.Printf("Player error: %v", event.Err)
+		app.finishHistory(models.HistoryFailed)
+		if app.playing_song != nil {
+			app.playing_box.SetText("Error playing: " + app.playing_song.Title + " - " + app.playing_song.Channel)
+		}
+	}
+	app.updateControlButton()
+}
+
+// stopPlayer saves the session and stops playback and searches before the
+// application exits
+func (app *App) stopPlayer() {
+	app.saveSession()
+	app.stopLoadingSongs()
+	app.stopTimer()
+	if app.subscriptions_cancel != nil {
+		app.subscriptions_cancel()
+	}
+	app.finishHistory(models.HistorySkipped)
+	if app.player != nil {
+		app.player.Stop()
+	}
+}
+
 func (app *App) updateControlButton() {
-	state := services.GetPlayerState()
-	if state == "playing" {
+	state := app.playerState()
+	if state == services.PlayerLoading {
+		app.control_button.SetLabel("⏳ Loading")
+		app.playing_box.SetTextColor(tcell.ColorYellow)
+		app.playing_box.SetTitleColor(tcell.ColorYellow)
+	} else if state == services.PlayerPlaying {
 		app.control_button.SetLabel("⏸️ Pause")
 		app.playing_box.SetTextColor(tcell.ColorGreen)
 		app.playing_box.SetTitleColor(tcell.ColorGreen)
 		app.start_time = time.Now().Add(-app.elapsed)
-		if app.timer != nil {
-			app.timer.Reset(time.Second)
-		}
+		app.startTimer()
 	} else {
 		app.control_button.SetLabel("▶️ Play")
 		app.playing_box.SetTextColor(tcell.ColorYellow)
 		app.playing_box.SetTitleColor(tcell.ColorYellow)
-		if state == "paused" {
+		if state == services.PlayerPaused {
 			app.elapsed = time.Since(app.start_time)
+			if app.position_known {
+				app.elapsed = app.position
+			}
 		}
-		if state == "stopped" {
+		if !state.Active() {
 			app.elapsed = app.duration
 		}
 	}
@@ -138,8 +530,11 @@ func (app *App) updateControlButton() {
 }
 
 func (app *App) playSong(song *models.Video) {
-	if app.timer != nil {
-		app.timer.Stop()
+	app.stopTimer()
+
+	if app.player == nil {
+		log.Printf("Error playing media: no suitable media player found")
+		return
 	}
 
 	audioUrl, err := services.GetVideoAudioUrl(song.ID)
@@ -148,115 +543,408 @@ func (app *App) playSong(song *models.Video) {
 		return
 	}
 
-	if err := services.PlayMedia(audioUrl); err != nil {
+	app.finishHistory(models.HistorySkipped)
+	if err := app.player.Play(audioUrl); err != nil {
 		log.Printf("Error playing media: %v", err)
 		return
 	}
+	app.startHistory(*song)
+	app.markUploadsSeen(*song)
 
 	app.playing_song = song
 	app.playing_url = audioUrl
-	app.duration = parseDuration(song.Duration)
+	app.duration = services.ParseDuration(song.Duration)
 	app.start_time = time.Now()
 	app.elapsed = 0
+	app.position = 0
+	app.position_known = false
 
-	// Create and start the timer
-	app.timer = time.NewTimer(time.Second)
-	go func() {
-		for range app.timer.C {
-			app.app.QueueUpdateDraw(func() {
-				app.updateTimeDisplay()
-			})
-			app.timer.Reset(time.Second)
+	app.playing_box.Clear()
+	app.playing_box.SetText("Now Playing: " + song.Title + " - " + song.Channel)
+	app.updateControlButton()
+}
+
+func (app *App) setQueueTableHeader() {
+	headers := []string{"", "Title", "Channel", "Duration"}
+	for i, header := range headers {
+		app.queue_list.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.queue_list.SetFixed(1, 0)
+}
+
+// refreshQueue redraws the queue pane after the queue has changed
+func (app *App) refreshQueue() {
+	row, _ := app.queue_list.GetSelection()
+	app.queue_list.Clear()
+	app.setQueueTableHeader()
+
+	current := app.queue.CurrentIndex()
+	for i, song := range app.queue.Items() {
+		marker := ""
+		color := tcell.ColorWhite
+		if i == current {
+			marker = "▶"
+			color = tcell.ColorGreen
 		}
-	}()
+		app.queue_list.SetCell(i+1, 0, tview.NewTableCell(marker).SetTextColor(color))
+		app.queue_list.SetCell(i+1, 1, tview.NewTableCell(song.Title).SetMaxWidth(30).SetTextColor(color))
+		app.queue_list.SetCell(i+1, 2, tview.NewTableCell(song.Channel).SetMaxWidth(15).SetTextColor(color))
+		app.queue_list.SetCell(i+1, 3, tview.NewTableCell(formatTotal(services.ParseDuration(song.Duration))).SetTextColor(color))
+	}
+	if row > 0 {
+		app.queue_list.Select(min(row, app.queue.Len()), 0)
+	}
+
+	app.playlist_box.SetTitle(fmt.Sprintf("Playlist (%d tracks, %s left)",
+		app.queue.Len(), services.FormatDuration(app.queue.Remaining())))
+}
+
+// selectedSong returns the song on the selected row of the music list
+func (app *App) selectedSong() *models.Video {
+	return tableSong(app.music_list)
+}
+
+// handleSongKey handles the keys that act on a song in a list: the track
+// keys and the ones that queue it, add it to a playlist or block its
+// channel. It returns false for any other key.
+func (app *App) handleSongKey(event *tcell.EventKey, song models.Video) bool {
+	if app.handleTrackKey(event, song) {
+		return true
+	}
+	switch event.Rune() {
+	case 'a':
+		app.enqueue(song, false)
+	case 'A':
+		app.enqueue(song, true)
+	case 'P':
+		app.addToPlaylist(song)
+	case 'B':
+		app.blockChannel(song)
+	default:
+		return false
+	}
+	return true
+}
+
+// enqueue adds a song to the end of the queue, or right after the current
+// track when next is set
+func (app *App) enqueue(song models.Video, next bool) {
+	if next {
+		app.queue.InsertNext(song)
+	} else {
+		app.queue.Append(song)
+	}
+	app.refreshQueue()
+}
+
+// playQueueIndex plays the track at index in the queue
+func (app *App) playQueueIndex(index int) {
+	if song, ok := app.queue.Select(index); ok {
+		app.playQueued(song)
+	}
+}
+
+// playQueued plays a song that has just become the current track of the queue
+func (app *App) playQueued(song models.Video) {
+	app.refreshQueue()
+	app.playSong(&song)
+	app.radio.Played(song.ID)
+	app.fillRadio(false)
+}
+
+// fillRadio tops up the queue with tracks related to the current one when
+// radio mode is on and the queue is about to run out. With play set, the
+// first new track starts playing as soon as it arrives.
+func (app *App) fillRadio(play bool) {
+	if !app.settings.Radio || app.radio_loading || app.playing_song == nil {
+		return
+	}
+	if !play && app.queue.Len()-app.queue.CurrentIndex()-1 >= radioLowWater {
+		return
+	}
 
-	if services.GetPlayerState() == "stopped" {
+	seed := *app.playing_song
+	settings := app.settings
+	exclude := make(map[string]bool)
+	for _, song := range app.queue.Items() {
+		exclude[song.ID] = true
+	}
+
+	app.radio_loading = true
+	go func() {
+		songs, err := app.radio.Related(seed, radioBatch, settings, exclude)
 		app.app.QueueUpdateDraw(func() {
-			app.control_button.SetLabel("▶️ Play")
-			app.playing_box.SetTextColor(tcell.ColorYellow)
-			app.playing_box.SetTitleColor(tcell.ColorYellow)
-			app.elapsed = app.duration
-			app.updateTimeDisplay()
+			app.radio_loading = false
+			if err != nil {
+				log.Printf("Error finding related tracks: %v", err)
+				return
+			}
+
+			first := app.queue.Len()
+			for _, song := range songs {
+				app.queue.Append(song)
+			}
+			app.refreshQueue()
+			if play && len(songs) > 0 && !app.playerState().Active() {
+				app.playQueueIndex(first)
+			}
 		})
-		if app.timer != nil {
-			app.timer.Stop()
-		}
+	}()
+}
+
+func (app *App) toggleRadio() {
+	app.settings.Radio = !app.settings.Radio
+	app.saveSettings()
+	app.updateModeDisplay()
+	app.fillRadio(false)
+}
+
+// blockChannel stops the radio from picking tracks from a channel
+func (app *App) blockChannel(song models.Video) {
+	if song.Channel == "" {
+		return
 	}
+	app.showConfirm(fmt.Sprintf("Never pick tracks from %q for the radio?", song.Channel), func() {
+		app.settings.BlockedChannels = append(app.settings.BlockedChannels, song.Channel)
+		app.saveSettings()
+	})
+}
 
-	app.playing_box.Clear()
-	app.playing_box.SetText("Now Playing: " + song.Title + " - " + song.Channel)
-	app.updateControlButton()
+func (app *App) playNext() {
+	if song, ok := app.queue.Next(); ok {
+		app.playQueued(song)
+	}
+}
+
+func (app *App) playPrevious() {
+	if song, ok := app.queue.Previous(); ok {
+		app.playQueued(song)
+	}
+}
+
+// cycleRepeat switches between repeat off, all and one
+func (app *App) cycleRepeat() {
+	app.settings.Repeat = services.NextRepeatMode(app.settings.Repeat)
+	app.queue.SetRepeat(app.settings.Repeat)
+	app.saveSettings()
+	app.updateModeDisplay()
+}
+
+func (app *App) toggleShuffle() {
+	app.settings.Shuffle = !app.settings.Shuffle
+	app.queue.SetShuffle(app.settings.Shuffle)
+	app.saveSettings()
+	app.refreshQueue()
+	app.updateModeDisplay()
+}
+
+func (app *App) updateModeDisplay() {
+	repeat := "➡️ off"
+	switch app.settings.Repeat {
+	case models.RepeatAll:
+		repeat = "🔁 all"
+	case models.RepeatOne:
+		repeat = "🔂 one"
+	}
+
+	shuffle := "[gray]🔀 off[-]"
+	if app.settings.Shuffle {
+		shuffle = "🔀 on"
+	}
+	radio := "[gray]📻[-]"
+	if app.settings.Radio {
+		radio = "📻"
+	}
+	app.mode_box.SetText(repeat + "  " + shuffle + "  " + radio)
+}
+
+// seekTo jumps to an absolute position in the current track
+func (app *App) seekTo(position time.Duration) {
+	if app.player == nil || app.playing_song == nil {
+		return
+	}
+	if err := app.player.Seek(position); err != nil {
+		log.Printf("Error seeking: %v", err)
+		return
+	}
+	app.setPosition(position)
+}
+
+// seekBy moves the position in the current track forwards or backwards
+func (app *App) seekBy(offset time.Duration) {
+	if app.player == nil || app.playing_song == nil {
+		return
+	}
+	position, err := services.SeekBy(app.player, offset)
+	if err != nil {
+		log.Printf("Error seeking: %v", err)
+		return
+	}
+	app.setPosition(position)
+}
+
+func (app *App) setPosition(position time.Duration) {
+	app.position = position
+	app.position_known = true
+	app.elapsed = position
+	app.start_time = time.Now().Add(-position)
 	app.updateTimeDisplay()
 }
 
-func formatDuration(d time.Duration) string {
-	d = d.Round(time.Second)
-	m := d / time.Minute
-	d -= m * time.Minute
-	s := d / time.Second
-	return fmt.Sprintf("%02d:%02d", m, s)
+// changeVolume raises or lowers the volume by delta percent, unmuting if needed
+func (app *App) changeVolume(delta int) {
+	app.settings.Volume = max(0, min(app.settings.Volume+delta, 100))
+	app.settings.Muted = false
+	app.applyVolume()
+	app.saveSettings()
+}
+
+func (app *App) toggleMute() {
+	app.settings.Muted = !app.settings.Muted
+	app.applyVolume()
+	app.saveSettings()
+}
+
+// saveSettings remembers the settings for the next run
+func (app *App) saveSettings() {
+	if err := services.SaveSettings(app.settings); err != nil {
+		log.Printf("Error saving settings: %v", err)
+	}
+}
+
+// applyVolume sends the volume to the player, which keeps it for new tracks
+func (app *App) applyVolume() {
+	if app.player != nil {
+		volume := app.settings.Volume
+		if app.settings.Muted {
+			volume = 0
+		}
+		if err := app.player.SetVolume(volume); err != nil {
+			log.Printf("Error setting volume: %v", err)
+		}
+	}
+
+	app.updateVolumeDisplay()
+}
+
+func (app *App) updateVolumeDisplay() {
+	if app.settings.Muted {
+		app.volume_box.SetText("🔇 Muted")
+		app.volume_box.SetTextColor(tcell.ColorGray)
+		return
+	}
+	app.volume_box.SetText(fmt.Sprintf("🔊 %d%%", app.settings.Volume))
+	app.volume_box.SetTextColor(tcell.ColorWhite)
+}
+
+// startTimer polls the player for the playback position every second
+func (app *App) startTimer() {
+	if app.timer != nil {
+		return
+	}
+
+	app.timer = time.NewTicker(time.Second)
+	app.timer_done = make(chan struct{})
+	go func(ticker *time.Ticker, done chan struct{}) {
+		for {
+			select {
+			case <-ticker.C:
+				app.refreshPosition()
+			case <-done:
+				return
+			}
+		}
+	}(app.timer, app.timer_done)
+}
+
+func (app *App) stopTimer() {
+	if app.timer == nil {
+		return
+	}
+	app.timer.Stop()
+	close(app.timer_done)
+	app.timer = nil
+}
+
+// refreshPosition asks the player for the position and duration of the
+// current track. It runs outside the UI goroutine because the player may
+// have to talk to another process.
+func (app *App) refreshPosition() {
+	if app.player == nil {
+		return
+	}
+
+	position, posErr := app.player.Position()
+	duration, durErr := app.player.Duration()
+
+	app.app.QueueUpdateDraw(func() {
+		app.position_known = posErr == nil
+		if posErr == nil {
+			app.position = position
+		}
+		if durErr == nil && duration > 0 {
+			app.duration = duration
+		}
+		app.updateTimeDisplay()
+	})
 }
 
-func parseDuration(dur string) time.Duration {
-	parts := strings.Split(dur, ":")
-	if len(parts) == 1 {
-		// Only seconds
-		sec, _ := strconv.Atoi(parts[0])
-		return time.Duration(sec) * time.Second
-	} else if len(parts) == 2 {
-		// Minutes:Seconds
-		min, _ := strconv.Atoi(parts[0])
-		sec, _ := strconv.Atoi(parts[1])
-		return time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
-	} else if len(parts) == 3 {
-		// Hours:Minutes:Seconds
-		hour, _ := strconv.Atoi(parts[0])
-		min, _ := strconv.Atoi(parts[1])
-		sec, _ := strconv.Atoi(parts[2])
-		return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+// formatTotal formats the length of a track, which is unknown for live streams
+func formatTotal(d time.Duration) string {
+	if d <= 0 {
+		return "--:--"
 	}
-	return 0
+	return services.FormatDuration(d)
 }
 
 func (app *App) updateTimeDisplay() {
 	if app.playing_song == nil {
 		app.playing_box.SetTitle(" 0:00 / 0:00 ")
+		app.progress_bar.SetProgress(0, 0)
 		return
 	}
 
 	var elapsed time.Duration
-	state := services.GetPlayerState()
+	state := app.playerState()
 
-	if state == "playing" {
+	if state == services.PlayerPlaying {
+		// Fall back to the wall clock for backends that can't report a position
 		elapsed = time.Since(app.start_time)
+		if app.position_known {
+			elapsed = app.position
+		}
 		app.playing_box.SetTextColor(tcell.ColorGreen)
 		app.playing_box.SetTitleColor(tcell.ColorGreen)
-		if app.timer != nil {
-			app.timer.Reset(time.Second)
-		}
-	} else if state == "paused" {
+	} else if state == services.PlayerPaused {
 		elapsed = app.elapsed
 		app.playing_box.SetTextColor(tcell.ColorYellow)
 		app.playing_box.SetTitleColor(tcell.ColorYellow)
-	} else if state == "stopped" {
+	} else if !state.Active() {
 		elapsed = app.duration // Show full duration when stopped
 		app.playing_box.SetTextColor(tcell.ColorYellow)
 		app.playing_box.SetTitleColor(tcell.ColorYellow)
 		app.control_button.SetLabel("▶️ Play")
-		if app.timer != nil {
-			app.timer.Stop()
-		}
+		app.stopTimer()
 	}
 
-	if elapsed > app.duration {
+	if app.duration > 0 && elapsed > app.duration {
 		elapsed = app.duration
 	}
 
 	title := fmt.Sprintf(" %s / %s ",
-		formatDuration(elapsed),
-		formatDuration(app.duration))
+		services.FormatDuration(elapsed),
+		formatTotal(app.duration))
 
 	app.playing_box.SetTitle(title)
+
+	color := tcell.ColorYellow
+	if state == services.PlayerPlaying {
+		color = tcell.ColorGreen
+	}
+	app.progress_bar.SetProgress(elapsed, app.duration).SetColor(color)
 }
 
 func main() {
@@ -273,29 +961,136 @@ func main() {
 	defer logFile.Close()
 	log.SetOutput(logFile)
 
+	player, err := services.NewPlayer()
+	if err != nil {
+		log.Printf("Error creating player: %v", err)
+	}
+
+	settings, err := services.LoadSettings()
+	if err != nil {
+		log.Printf("Error loading settings: %v", err)
+	}
+
+	// Initialize app
+	app := NewApp(player, settings)
+	app.watchPlayer()
+
+	app.playlists, err = services.LoadPlaylistStore()
+	if err != nil {
+		log.Printf("Error loading playlists: %v", err)
+	}
+
+	app.searches, err = services.LoadSearchStore()
+	if err != nil {
+		log.Printf("Error loading searches: %v", err)
+	}
+
+	app.subscriptions, err = services.LoadSubscriptionStore()
+	if err != nil {
+		log.Printf("Error loading subscriptions: %v", err)
+	}
+
+	app.library, err = services.LoadLibraryStore()
+	if err != nil {
+		log.Printf("Error loading library: %v", err)
+	}
+
+	app.history, err = services.LoadHistoryStore()
+	if err != nil {
+		log.Printf("Error loading history: %v", err)
+	} else {
+		// Keep the radio from picking what was played recently
+		for _, entry := range app.history.Entries() {
+			app.radio.Played(entry.Video.ID)
+		}
+	}
+
 	// Setup signal handling for cleanup
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
 		sig := <-c
 		log.Printf("Received signal %v, cleaning up...", sig)
-		services.Cleanup()
-		os.Exit(0)
+		// The session is saved from the state the event loop owns
+		app.app.QueueUpdate(func() {
+			app.stopPlayer()
+			app.app.Stop()
+		})
 	}()
 
-	// Initialize app
-	app := NewApp()
-
 	// Add input capture to handle Ctrl+C and 'q' globally
 	app.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyCtrlC || event.Rune() == 'q' {
-			if app.timer != nil {
-				app.timer.Stop()
-			}
-			services.Cleanup()
+		if event.Key() == tcell.KeyCtrlC {
+			app.stopPlayer()
 			app.app.Stop()
 			return nil
 		}
+		// Dialogs get every key
+		if app.dialogOpen() {
+			return event
+		}
+		if event.Key() == tcell.KeyTab || event.Key() == tcell.KeyBacktab {
+			app.cycleFocus(event.Key() == tcell.KeyBacktab)
+			return nil
+		}
+
+		// Let text fields receive the characters used as shortcuts
+		focus := app.app.GetFocus()
+		if _, ok := focus.(*tview.InputField); ok {
+			return event
+		}
+		if strings.ContainsRune(app.local_keys[focus], event.Rune()) {
+			return event
+		}
+
+		switch event.Rune() {
+		case 'q':
+			app.stopPlayer()
+			app.app.Stop()
+			return nil
+		case ',':
+			app.seekBy(-5 * time.Second)
+			return nil
+		case '.':
+			app.seekBy(5 * time.Second)
+			return nil
+		case '<':
+			app.seekBy(-30 * time.Second)
+			return nil
+		case '>':
+			app.seekBy(30 * time.Second)
+			return nil
+		case '+', '=':
+			app.changeVolume(5)
+			return nil
+		case '-':
+			app.changeVolume(-5)
+			return nil
+		case 'm':
+			app.toggleMute()
+			return nil
+		case 'n':
+			app.playNext()
+			return nil
+		case 'p':
+			app.playPrevious()
+			return nil
+		case 'r':
+			app.cycleRepeat()
+			return nil
+		case 's':
+			app.toggleShuffle()
+			return nil
+		case 'o':
+			app.toggleRadio()
+			return nil
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			// Jump to 0%, 10%, ... 90% of the track
+			if app.duration > 0 {
+				app.seekTo(app.duration * time.Duration(event.Rune()-'0') / 10)
+			}
+			return nil
+		}
 		return event
 	})
 
@@ -307,24 +1102,101 @@ func main() {
 	flex_box := tview.NewFlex().SetDirection(tview.FlexColumn)
 
 	// Container - Music box
-	music_box := tview.NewFlex()
+	music_box := app.view_box
 	music_box.SetDirection(tview.FlexRow)
 	music_box.SetBorder(true)
 	music_box.SetTitle("Music")
 	music_box.SetTitleAlign(tview.AlignLeft)
 
-	app.setMusicTableHeader()
-	app.initMusicData(5)
+	setSongTableHeader(app.music_list)
+	app.addView("music", "Music", app.music_list, nil, app.music_list)
+	app.buildPlaylistsView()
+	app.buildSavedSearchesView()
+	app.buildFavoritesView()
+	app.buildHistoryView()
+	app.buildChannelView()
+	app.buildSubscriptionsView()
+	app.showView("music")
 
-	music_box.AddItem(app.music_list, 0, 1, true)
+	session, err := services.LoadSession()
+	if err != nil {
+		log.Printf("Error loading session: %v", err)
+	}
+
+	// A playlist URL or id can be given on the command line instead of a search
+	resume := false
+	if len(os.Args) > 1 {
+		if id, ok := services.ParsePlaylistID(os.Args[1]); ok {
+			app.importPlaylist(id)
+		} else {
+			log.Printf("Warning: %q is not a YouTube playlist", os.Args[1])
+			app.initMusicData()
+		}
+	} else if session.Resumable() {
+		resume = true
+	} else {
+		app.initMusicData()
+	}
+
+	music_box.AddItem(app.pages, 0, 1, true)
 
 	// Container - Playlist box
-	playlist_box := tview.NewFlex()
+	playlist_box := app.playlist_box
 	playlist_box.SetDirection(tview.FlexRow)
 	playlist_box.SetBorder(true)
-	playlist_box.SetTitle("Playlist")
 	playlist_box.SetTitleAlign(tview.AlignLeft)
 
+	app.queue_list.SetSelectable(true, false)
+	app.queue.SetRepeat(app.settings.Repeat)
+	app.queue.SetShuffle(app.settings.Shuffle)
+	app.refreshQueue()
+	app.queue_list.SetSelectedFunc(func(row, column int) {
+		if row > 0 { // Ignore header row
+			app.playQueueIndex(row - 1)
+			// When shuffled the track has moved after the one played before it
+			app.queue_list.Select(app.queue.CurrentIndex()+1, 0)
+		}
+	})
+	app.queue_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'E' {
+			app.exportPlaylistFile(app.queuePlaylist())
+			return nil
+		}
+
+		row, _ := app.queue_list.GetSelection()
+		index := row - 1
+		if index < 0 || index >= app.queue.Len() {
+			return event
+		}
+
+		switch {
+		case event.Key() == tcell.KeyDelete || event.Rune() == 'd':
+			app.queue.Remove(index)
+		case event.Rune() == 'K':
+			if app.queue.Move(index, index-1) {
+				app.queue_list.Select(row-1, 0)
+			}
+		case event.Rune() == 'J':
+			if app.queue.Move(index, index+1) {
+				app.queue_list.Select(row+1, 0)
+			}
+		case event.Rune() == 'P':
+			app.addToPlaylist(app.queue.Items()[index])
+			return nil
+		case event.Rune() == 'B':
+			app.blockChannel(app.queue.Items()[index])
+			return nil
+		case app.handleTrackKey(event, app.queue.Items()[index]):
+			return nil
+		default:
+			return event
+		}
+		app.refreshQueue()
+		return nil
+	})
+
+	playlist_box.AddItem(app.queue_list, 0, 1, false)
+
 	// Container - Content box
 	content_box := tview.NewFlex().SetDirection(tview.FlexRow)
 	content_box.AddItem(music_box, 0, 1, false)
@@ -350,22 +1222,42 @@ func main() {
 			return
 		}
 
-		state := services.GetPlayerState()
-		if state == "playing" {
-			services.PauseMedia()
-		} else {
-			if services.IsMediaFinished() {
-				app.playSong(app.playing_song)
-			} else {
-				services.PlayMedia(app.playing_url)
+		switch app.playerState() {
+		case services.PlayerLoading:
+			// Wait for the track to start
+		case services.PlayerPlaying:
+			if err := app.player.Pause(); err != nil {
+				log.Printf("Error pausing media: %v", err)
+			}
+		case services.PlayerPaused:
+			if err := app.player.Resume(); err != nil {
+				log.Printf("Error resuming media: %v", err)
 			}
+		default:
+			app.playSong(app.playing_song)
 		}
 		app.updateControlButton()
 	})
 
 	button_control_box.AddItem(app.control_button, 0, 1, true)
 
-	player_box.AddItem(app.playing_box, 0, 5, false)
+	// Set up the progress bar
+	app.progress_bar.SetBorder(true)
+	app.progress_bar.SetSeekFunc(app.seekTo)
+
+	// Set up the volume indicator
+	app.volume_box.SetBorder(true).SetTitle("Volume")
+	app.applyVolume()
+
+	// Set up the repeat and shuffle indicator
+	app.mode_box.SetBorder(true).SetTitle("Mode")
+	app.mode_box.SetDynamicColors(true)
+	app.updateModeDisplay()
+
+	player_box.AddItem(app.playing_box, 0, 3, false)
+	player_box.AddItem(app.volume_box, 12, 0, false)
+	player_box.AddItem(app.mode_box, 22, 0, false)
+	player_box.AddItem(app.progress_bar, 0, 2, false)
 	player_box.AddItem(button_control_box, 0, 1, false)
 
 	// Set up table selection handler
@@ -375,10 +1267,51 @@ func main() {
 			cell := app.music_list.GetCell(row, 0)
 			video, ok := cell.GetReference().(*models.Video)
 			if ok {
-				app.playSong(video)
+				app.playQueueIndex(app.queue.InsertNext(*video))
 			}
 		}
 	})
+	app.music_list.SetSelectionChangedFunc(func(row, column int) {
+		// Load the next page once the last song is reached
+		if row >= len(app.music_songs) {
+			app.loadMoreSongs("Loading more...")
+		}
+	})
+	app.music_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyDown || event.Rune() == 'j' {
+			if row, _ := app.music_list.GetSelection(); row >= len(app.music_songs) {
+				app.loadMoreSongs("Loading more...")
+			}
+		}
+		if event.Key() == tcell.KeyEscape {
+			app.cancelLoadingSongs()
+			return nil
+		}
+		if song := app.selectedSong(); song != nil && app.handleSongKey(event, *song) {
+			return nil
+		}
+		switch event.Rune() {
+		case 'F':
+			app.showSearchFilters()
+			return nil
+		case 'W':
+			app.saveSearch()
+			return nil
+		case 'L':
+			// Add everything in the list to the queue
+			for _, song := range app.music_songs {
+				app.queue.Append(song)
+			}
+			app.refreshQueue()
+			return nil
+		case 'S':
+			if len(app.music_songs) > 0 {
+				app.createPlaylist("", app.music_songs)
+			}
+			return nil
+		}
+		return event
+	})
 
 	// Header box
 	header_box := tview.NewFlex().SetDirection(tview.FlexColumn)
@@ -391,36 +1324,73 @@ func main() {
 	status_box.SetText("...")
 
 	// Search box
-	search_box := tview.NewInputField()
+	search_box := app.search_box
 	search_box.SetBorder(true)
 	search_box.SetTitle("Search")
 	search_box.SetFieldBackgroundColor(tcell.ColorNone)
 	search_box.SetFieldTextColor(tcell.ColorWhite)
 	search_box.SetTitleAlign(tview.AlignLeft)
 	search_box.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			app.cancelLoadingSongs()
+		}
 		if key == tcell.KeyEnter {
-			text := search_box.GetText()
-			if text != "" {
-				app.performSearch(text, 5)
-				app.app.SetFocus(app.music_list) // Focus directly on the table for navigation
+			app.submitSearch(search_box.GetText())
+		}
+	})
+	search_box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyDown:
+			// The arrows move through the suggestions while they are showing
+			step := -1
+			if event.Key() == tcell.KeyDown {
+				step = 1
 			}
+			if !app.suggest_open && app.recallSearch(step) {
+				return nil
+			}
+		case tcell.KeyEscape, tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab:
+			app.suggest_open = false
 		}
+		return event
 	})
+	search_box.SetBlurFunc(func() {
+		app.suggest_open = false
+	})
+	app.setupSuggestions()
 
 	// Set up header box
 	header_box.AddItem(search_box, 0, 1, false)
 	// header_box.AddItem(status_box, 0, 1, false)
 
 	// Menu
-	menu := tview.NewList()
-	menu.AddItem("Settings", "", 's', nil)
+	menu := app.menu
+	menu.AddItem("Music", "", 0, func() {
+		app.showView("music")
+	})
+	menu.AddItem("Playlists", "", 0, func() {
+		app.showView("playlists")
+	})
+	menu.AddItem("Saved searches", "", 0, func() {
+		app.showView("searches")
+	})
+	app.subscriptions_item = menu.GetItemCount()
+	menu.AddItem("Subscriptions", "", 0, func() {
+		app.showView("subscriptions")
+	})
+	menu.AddItem("Favorites", "", 0, func() {
+		app.showView("favorites")
+	})
+	menu.AddItem("History", "", 0, func() {
+		app.showView("history")
+	})
+	menu.AddItem("Settings", "", 's', app.showSettings)
 	menu.AddItem("Exit", "", 'q', func() {
-		if app.timer != nil {
-			app.timer.Stop()
-		}
-		services.Cleanup()
+		app.stopPlayer()
 		app.app.Stop()
 	})
+	// s opens Settings rather than toggling shuffle while the Menu has focus
+	app.local_keys[menu] = "s"
 	menu.SetBorder(true).SetTitle("Menu")
 	menu.SetTitleAlign(tview.AlignLeft)
 
@@ -432,8 +1402,14 @@ func main() {
 	flex_box.AddItem(menu, 0, 1, false)
 	flex_box.AddItem(content_box, 0, 5, false)
 
+	app.root.AddPage("main", main_box, true, true)
+	app.watchSubscriptions()
+	if resume {
+		app.offerResume(session)
+	}
+
 	if err := app.app.
-		SetRoot(main_box, true).
+		SetRoot(app.root, true).
 		EnableMouse(true).
 		Run(); err != nil {
 		panic(err)
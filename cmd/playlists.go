@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// buildPlaylistsView lays out the saved playlists next to the tracks of the
+// selected playlist
+func (app *App) buildPlaylistsView() {
+	app.playlist_names.SetSelectable(true, false)
+	app.playlist_names.SetSelectionChangedFunc(func(row, column int) {
+		app.refreshPlaylistEntries()
+	})
+	app.playlist_names.SetSelectedFunc(func(row, column int) {
+		// Replace the queue with the playlist and start playing it
+		if playlist, ok := app.selectedPlaylist(); ok {
+			videos := app.playlistVideos(playlist)
+			if len(videos) == 0 {
+				return
+			}
+			app.queue.Clear()
+			for _, video := range videos {
+				app.queue.Append(video)
+			}
+			app.playQueueIndex(0)
+		}
+	})
+	app.playlist_names.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'c':
+			app.createPlaylist("", nil)
+		case 'Q':
+			app.createSmartPlaylist()
+		case 'e':
+			if playlist, ok := app.selectedPlaylist(); ok && playlist.Smart() {
+				app.showPrompt("Rules of "+playlist.Name, playlist.Query, func(query string) {
+					app.reportError(app.playlists.SetQuery(playlist.Name, query))
+					app.refreshPlaylists()
+				})
+			}
+		case 'R':
+			if playlist, ok := app.selectedPlaylist(); ok {
+				app.showPrompt("Rename playlist", playlist.Name, func(name string) {
+					app.reportError(app.playlists.Rename(playlist.Name, name))
+					app.refreshPlaylists()
+				})
+			}
+		case 'd':
+			if playlist, ok := app.selectedPlaylist(); ok {
+				app.showConfirm(fmt.Sprintf("Delete playlist %q?", playlist.Name), func() {
+					app.reportError(app.playlists.Delete(playlist.Name))
+					app.refreshPlaylists()
+				})
+			}
+		case 'E':
+			if playlist, ok := app.selectedPlaylist(); ok {
+				app.exportPlaylistFile(app.playlistSnapshot(playlist))
+			}
+		case 'I':
+			app.importPlaylistFile()
+		case 'a':
+			// Add the whole playlist to the end of the queue
+			if playlist, ok := app.selectedPlaylist(); ok {
+				for _, video := range app.playlistVideos(playlist) {
+					app.queue.Append(video)
+				}
+				app.refreshQueue()
+			}
+		default:
+			return event
+		}
+		return nil
+	})
+
+	app.playlist_entries.SetSelectable(true, false)
+	app.playlist_entries.SetSelectedFunc(func(row, column int) {
+		if video, ok := app.selectedPlaylistEntry(); ok {
+			app.playQueueIndex(app.queue.InsertNext(video))
+		}
+	})
+	app.playlist_entries.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'a':
+			if video, ok := app.selectedPlaylistEntry(); ok {
+				app.enqueue(video, false)
+			}
+			return nil
+		case 'c':
+			if video, ok := app.selectedPlaylistEntry(); ok {
+				app.openChannel(video)
+			}
+			return nil
+		}
+
+		// The tracks of a smart playlist follow from its rules
+		playlist, ok := app.selectedPlaylist()
+		row, _ := app.playlist_entries.GetSelection()
+		index := row - 1
+		if !ok || playlist.Smart() || index < 0 || index >= len(playlist.Entries) {
+			return event
+		}
+
+		switch {
+		case event.Key() == tcell.KeyDelete || event.Rune() == 'd':
+			app.reportError(app.playlists.Remove(playlist.Name, index))
+		case event.Rune() == 'K':
+			if index > 0 && app.reportError(app.playlists.Move(playlist.Name, index, index-1)) {
+				app.playlist_entries.Select(row-1, 0)
+			}
+		case event.Rune() == 'J':
+			if index < len(playlist.Entries)-1 && app.reportError(app.playlists.Move(playlist.Name, index, index+1)) {
+				app.playlist_entries.Select(row+1, 0)
+			}
+		default:
+			return event
+		}
+		app.refreshPlaylistEntries()
+		return nil
+	})
+
+	layout := tview.NewFlex().SetDirection(tview.FlexColumn).
+		AddItem(app.playlist_names, 0, 1, true).
+		AddItem(app.playlist_entries, 0, 3, false)
+	app.addView("playlists", "Playlists", layout, app.refreshPlaylists, app.playlist_names, app.playlist_entries)
+}
+
+// refreshPlaylists redraws the list of saved playlists
+func (app *App) refreshPlaylists() {
+	row, _ := app.playlist_names.GetSelection()
+	app.playlist_names.Clear()
+	app.playlist_names.SetCell(0, 0, tview.NewTableCell("Name").
+		SetSelectable(false).
+		SetTextColor(tcell.ColorYellow).
+		SetAttributes(tcell.AttrBold))
+	app.playlist_names.SetCell(0, 1, tview.NewTableCell("Tracks").
+		SetSelectable(false).
+		SetTextColor(tcell.ColorYellow).
+		SetAttributes(tcell.AttrBold))
+	app.playlist_names.SetFixed(1, 0)
+
+	if app.playlists == nil {
+		return
+	}
+	playlists := app.playlists.Playlists()
+	for i, playlist := range playlists {
+		name := playlist.Name
+		if playlist.Smart() {
+			name = "⚡" + name
+		}
+		app.playlist_names.SetCell(i+1, 0, tview.NewTableCell(name).SetReference(playlist.Name))
+		app.playlist_names.SetCell(i+1, 1, tview.NewTableCell(fmt.Sprint(len(app.playlistVideos(playlist)))))
+	}
+	if len(playlists) > 0 {
+		app.playlist_names.Select(max(1, min(row, len(playlists))), 0)
+	}
+	app.refreshPlaylistEntries()
+}
+
+// refreshPlaylistEntries redraws the tracks of the selected playlist
+func (app *App) refreshPlaylistEntries() {
+	row, _ := app.playlist_entries.GetSelection()
+	app.playlist_entries.Clear()
+	headers := []string{"Title", "Channel", "Duration", "Added"}
+	for i, header := range headers {
+		app.playlist_entries.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.playlist_entries.SetFixed(1, 0)
+
+	playlist, ok := app.selectedPlaylist()
+	if !ok {
+		return
+	}
+	playlist = app.playlistSnapshot(playlist)
+	for i, entry := range playlist.Entries {
+		added := entry.AddedAt.Format("2006-01-02")
+		if playlist.Smart() {
+			added = ""
+		}
+		app.playlist_entries.SetCell(i+1, 0, tview.NewTableCell(entry.Video.Title).SetMaxWidth(30).SetReference(entry.Video))
+		app.playlist_entries.SetCell(i+1, 1, tview.NewTableCell(entry.Video.Channel).SetMaxWidth(15))
+		app.playlist_entries.SetCell(i+1, 2, tview.NewTableCell(formatTotal(services.ParseDuration(entry.Video.Duration))))
+		app.playlist_entries.SetCell(i+1, 3, tview.NewTableCell(added))
+	}
+	if row > 0 && len(playlist.Entries) > 0 {
+		app.playlist_entries.Select(min(row, len(playlist.Entries)), 0)
+	}
+}
+
+// playlistVideos returns the tracks of a playlist, working them out from the
+// history and library for a smart playlist
+func (app *App) playlistVideos(playlist models.Playlist) []models.Video {
+	if !playlist.Smart() {
+		return playlist.Videos()
+	}
+	videos, err := services.EvaluateSmartPlaylist(playlist.Query, app.history, app.library)
+	if err != nil {
+		log.Printf("Error evaluating smart playlist %q: %v", playlist.Name, err)
+	}
+	return videos
+}
+
+// playlistSnapshot returns a copy of playlist holding the tracks it has right now
+func (app *App) playlistSnapshot(playlist models.Playlist) models.Playlist {
+	if !playlist.Smart() {
+		return playlist
+	}
+	now := time.Now()
+	playlist.Entries = nil
+	for _, video := range app.playlistVideos(playlist) {
+		playlist.Entries = append(playlist.Entries, models.PlaylistEntry{Video: video, AddedAt: now})
+	}
+	return playlist
+}
+
+func (app *App) selectedPlaylist() (models.Playlist, bool) {
+	if app.playlists == nil {
+		return models.Playlist{}, false
+	}
+	row, _ := app.playlist_names.GetSelection()
+	if row <= 0 {
+		return models.Playlist{}, false
+	}
+	name, ok := app.playlist_names.GetCell(row, 0).GetReference().(string)
+	if !ok {
+		return models.Playlist{}, false
+	}
+	return app.playlists.Get(name)
+}
+
+func (app *App) selectedPlaylistEntry() (models.Video, bool) {
+	row, _ := app.playlist_entries.GetSelection()
+	if row <= 0 {
+		return models.Video{}, false
+	}
+	video, ok := app.playlist_entries.GetCell(row, 0).GetReference().(models.Video)
+	return video, ok
+}
+
+// createPlaylist asks for a name, suggesting name, and creates a playlist
+// holding videos
+func (app *App) createPlaylist(name string, videos []models.Video) {
+	if app.playlists == nil {
+		return
+	}
+	app.showPrompt("New playlist", name, func(name string) {
+		if !app.reportError(app.playlists.Create(name)) {
+			return
+		}
+		if len(videos) > 0 {
+			app.reportError(app.playlists.Add(name, videos...))
+		}
+		app.refreshPlaylists()
+	})
+}
+
+// createSmartPlaylist asks for a name and the rules of a new smart playlist
+func (app *App) createSmartPlaylist() {
+	if app.playlists == nil {
+		return
+	}
+	app.showPrompt("New smart playlist", "", func(name string) {
+		app.showPrompt("Rules, e.g. rating >= 4 and played > 30d", "", func(query string) {
+			if strings.TrimSpace(query) == "" {
+				return
+			}
+			app.reportError(app.playlists.CreateSmart(name, query))
+			app.refreshPlaylists()
+		})
+	})
+}
+
+// addToPlaylist asks which playlist to add videos to
+func (app *App) addToPlaylist(videos ...models.Video) {
+	if app.playlists == nil || len(videos) == 0 {
+		return
+	}
+
+	var playlists []models.Playlist
+	options := []string{"+ New playlist"}
+	for _, playlist := range app.playlists.Playlists() {
+		if !playlist.Smart() {
+			playlists = append(playlists, playlist)
+			options = append(options, playlist.Name)
+		}
+	}
+
+	app.showChoice("Add to playlist", options, func(index int) {
+		if index == 0 {
+			app.createPlaylist("", videos)
+			return
+		}
+		app.reportError(app.playlists.Add(playlists[index-1].Name, videos...))
+		app.refreshPlaylists()
+	})
+}
+
+// reportError logs err and shows it to the user. It returns true if there
+// was no error.
+func (app *App) reportError(err error) bool {
+	if err == nil {
+		return true
+	}
+	log.Printf("Error: %v", err)
+	app.showMessage("Error: " + err.Error())
+	return false
+}
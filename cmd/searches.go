@@ -0,0 +1,225 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// buildSavedSearchesView lists the saved searches
+func (app *App) buildSavedSearchesView() {
+	app.saved_list.SetSelectable(true, false)
+	app.saved_list.SetSelectedFunc(func(row, column int) {
+		if search, ok := app.selectedSavedSearch(); ok {
+			app.runSavedSearch(search)
+		}
+	})
+	app.saved_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if app.searches == nil {
+			return event
+		}
+		if event.Rune() == 'c' {
+			app.showPrompt("Search to save", app.music_query, func(query string) {
+				if strings.TrimSpace(query) == "" {
+					app.showMessage("Search for something first")
+					return
+				}
+				app.saveSearchAs(query)
+			})
+			return nil
+		}
+
+		search, ok := app.selectedSavedSearch()
+		if !ok {
+			return event
+		}
+		switch event.Rune() {
+		case 'e':
+			app.showPrompt("Search for", search.Query, func(query string) {
+				app.reportError(app.searches.SetSavedQuery(search.Name, query))
+				app.refreshSavedSearches()
+			})
+		case 'R':
+			app.showPrompt("Rename saved search", search.Name, func(name string) {
+				app.reportError(app.searches.RenameSaved(search.Name, name))
+				app.refreshSavedSearches()
+			})
+		case 'd':
+			app.showConfirm("Delete saved search \""+search.Name+"\"?", func() {
+				app.reportError(app.searches.DeleteSaved(search.Name))
+				app.refreshSavedSearches()
+			})
+		default:
+			return event
+		}
+		return nil
+	})
+
+	app.addView("searches", "Saved searches", app.saved_list, app.refreshSavedSearches, app.saved_list)
+}
+
+// refreshSavedSearches redraws the saved searches
+func (app *App) refreshSavedSearches() {
+	row, _ := app.saved_list.GetSelection()
+	app.saved_list.Clear()
+	headers := []string{"Name", "Search", "Last run"}
+	for i, header := range headers {
+		app.saved_list.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.saved_list.SetFixed(1, 0)
+
+	if app.searches == nil {
+		return
+	}
+	saved := app.searches.Saved()
+	for i, search := range saved {
+		lastRun := "never"
+		if !search.LastRun.IsZero() {
+			lastRun = search.LastRun.Format("2006-01-02 15:04")
+		}
+		app.saved_list.SetCell(i+1, 0, tview.NewTableCell(search.Name).SetMaxWidth(20).SetReference(search))
+		app.saved_list.SetCell(i+1, 1, tview.NewTableCell(search.Query).SetMaxWidth(30))
+		app.saved_list.SetCell(i+1, 2, tview.NewTableCell(lastRun))
+	}
+	if len(saved) > 0 {
+		app.saved_list.Select(max(1, min(row, len(saved))), 0)
+	}
+}
+
+func (app *App) selectedSavedSearch() (models.SavedSearch, bool) {
+	row, _ := app.saved_list.GetSelection()
+	if row <= 0 {
+		return models.SavedSearch{}, false
+	}
+	search, ok := app.saved_list.GetCell(row, 0).GetReference().(models.SavedSearch)
+	return search, ok
+}
+
+// runSavedSearch runs a saved search again in the music list, highlighting
+// the results it didn't show before
+func (app *App) runSavedSearch(search models.SavedSearch) {
+	seen, err := app.searches.StartRun(search.Name)
+	if !app.reportError(err) {
+		return
+	}
+
+	app.showView("music")
+	app.search_box.SetText(search.Query)
+	app.performSearch(search.Query)
+	// performSearch has reset these, and results only arrive after this
+	app.music_saved = search.Name
+	app.music_seen = seen
+	app.app.SetFocus(app.music_list)
+}
+
+// saveSearch saves the search the music list is showing, asking for a name
+func (app *App) saveSearch() {
+	if app.searches == nil {
+		return
+	}
+	if app.music_query == "" {
+		app.showMessage("Search for something first")
+		return
+	}
+
+	app.saveSearchAs(app.music_query)
+}
+
+// saveSearchAs saves query, asking for a name
+func (app *App) saveSearchAs(query string) {
+	app.showPrompt("Save search as", query, func(name string) {
+		if !app.reportError(app.searches.SaveSearch(name, query)) {
+			return
+		}
+		// What is listed now is what the next run compares with
+		if search, ok := app.searches.GetSaved(name); ok && app.music_query == query {
+			app.music_saved = search.Name
+			app.markSeen(app.music_songs)
+		}
+		if app.current_view == "searches" {
+			app.refreshSavedSearches()
+		}
+	})
+}
+
+// markSeen records that the saved search being shown has listed songs
+func (app *App) markSeen(songs []models.Video) {
+	if app.music_saved == "" || app.searches == nil || len(songs) == 0 {
+		return
+	}
+	ids := make([]string, len(songs))
+	for i, song := range songs {
+		ids[i] = song.ID
+	}
+	if err := app.searches.MarkSeen(app.music_saved, ids...); err != nil {
+		log.Printf("Error saving searches: %v", err)
+	}
+}
+
+// recordSearch adds a search that was typed to the search history
+func (app *App) recordSearch(text string) {
+	app.search_recall = -1
+	if app.searches == nil {
+		return
+	}
+	if err := app.searches.Record(text); err != nil {
+		log.Printf("Error saving searches: %v", err)
+	}
+}
+
+// recallSearch puts the previous (step -1) or next (step 1) past search
+// into the search box. Going past the newest one brings back what was being
+// typed. It reports whether the key was used.
+func (app *App) recallSearch(step int) bool {
+	if app.searches == nil {
+		return false
+	}
+	history := app.searches.History()
+	text := app.search_box.GetText()
+
+	walking := app.search_recall >= 0 && app.search_recall < len(history) &&
+		history[app.search_recall] == text
+	if !walking {
+		// Only Up starts walking the history, Down opens the suggestions
+		if step > 0 || len(history) == 0 {
+			return false
+		}
+		app.search_draft = text
+		app.search_recall = len(history)
+		if text == history[len(history)-1] {
+			// The last search is still in the box
+			app.search_recall--
+		}
+	}
+
+	index := app.search_recall + step
+	switch {
+	case index < 0:
+		// Stay at the oldest search
+	case index >= len(history):
+		app.search_recall = -1
+		app.search_box.SetText(app.search_draft)
+	default:
+		app.search_recall = index
+		app.search_box.SetText(history[index])
+	}
+	return true
+}
+
+// pastSearches returns the search history, newest first
+func (app *App) pastSearches() []string {
+	if app.searches == nil {
+		return nil
+	}
+	history := app.searches.History()
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// saveSession remembers the queue, the current track and position, the view
+// and the search text so that they can be resumed on the next launch
+func (app *App) saveSession() {
+	session := models.Session{
+		Queue:    app.queue.Items(),
+		Current:  app.queue.CurrentIndex(),
+		Position: app.currentPosition(),
+		Ended:    app.playerState() == services.PlayerEnded,
+		View:     app.current_view,
+		Search:   app.search_box.GetText(),
+		Music:    models.MusicTrending,
+	}
+	switch {
+	case app.music_playlist != "":
+		session.Music, session.MusicID = models.MusicPlaylist, app.music_playlist
+	case app.music_query != "":
+		session.Music, session.MusicID = models.MusicSearch, app.music_query
+	}
+	if session.View == "channel" {
+		// The channel isn't remembered
+		session.View = "music"
+	}
+	if app.resume_position > 0 {
+		// The resumed track hasn't got going yet
+		session.Position = app.resume_position
+	}
+	if err := services.SaveSession(session); err != nil {
+		log.Printf("Error saving session: %v", err)
+	}
+}
+
+// currentPosition returns how far into the current track playback is
+func (app *App) currentPosition() time.Duration {
+	switch app.playerState() {
+	case services.PlayerPlaying:
+		if app.position_known {
+			return app.position
+		}
+		return time.Since(app.start_time)
+	case services.PlayerPaused:
+		return app.elapsed
+	}
+	return 0
+}
+
+// offerResume asks whether to carry on where the last session left off.
+// Trending songs are loaded if the answer is no.
+func (app *App) offerResume(session models.Session) {
+	text := "Resume where you left off?"
+	if song, ok := session.CurrentTrack(); ok {
+		if session.Ended {
+			text += fmt.Sprintf("\n\n%s - %s (finished)", song.Title, song.Channel)
+		} else {
+			text += fmt.Sprintf("\n\n%s - %s at %s", song.Title, song.Channel, services.FormatDuration(session.Position))
+		}
+	}
+	if len(session.Queue) > 0 {
+		text += fmt.Sprintf("\n%d tracks in the queue", len(session.Queue))
+	}
+	switch session.Music {
+	case models.MusicSearch:
+		text += fmt.Sprintf("\nSearch: %s", session.MusicID)
+	case models.MusicPlaylist:
+		text += fmt.Sprintf("\nPlaylist: %s", session.MusicID)
+	}
+
+	modal := tview.NewModal().
+		SetText(tview.Escape(text)).
+		AddButtons([]string{"Resume", "Start fresh"}).
+		SetDoneFunc(func(index int, label string) {
+			app.closeDialog()
+			if label == "Resume" {
+				app.resumeSession(session)
+			} else {
+				app.initMusicData()
+			}
+		})
+	app.showModal(modal)
+}
+
+// resumeSession restores a saved session and carries on playing
+func (app *App) resumeSession(session models.Session) {
+	app.search_box.SetText(session.Search)
+	switch session.Music {
+	case models.MusicSearch:
+		app.performSearch(session.MusicID)
+	case models.MusicPlaylist:
+		app.loadPlaylist(session.MusicID, false)
+	case "":
+		// Sessions saved before the source was recorded only have the search
+		if session.Search != "" {
+			app.performSearch(session.Search)
+		} else {
+			app.initMusicData()
+		}
+	default:
+		app.initMusicData()
+	}
+	if _, ok := app.views[session.View]; ok {
+		app.showView(session.View)
+	}
+
+	// The queue is restored in the order it had, shuffled or not
+	app.queue.Restore(session.Queue, session.Current)
+	app.refreshQueue()
+	if session.Ended {
+		// The track had finished, carry on with the one after it
+		if song, ok := app.queue.Advance(); ok {
+			app.playQueued(song)
+		}
+	} else if song, ok := app.queue.Current(); ok {
+		// The player can only seek once the track has started
+		app.resume_position = session.Position
+		app.playQueued(song)
+	}
+}
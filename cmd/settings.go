@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// showSettings opens a form for the settings that have no shortcut of their own
+func (app *App) showSettings() {
+	settings := app.settings
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Settings").SetTitleAlign(tview.AlignLeft)
+	form.SetFieldBackgroundColor(tcell.ColorDarkSlateGray)
+	form.AddInputField("Results per page", strconv.Itoa(settings.PageSize), 5, tview.InputFieldInteger, func(text string) {
+		if size, err := strconv.Atoi(text); err == nil {
+			settings.PageSize = max(1, min(size, services.MaxPageSize))
+		}
+	})
+	form.AddCheckbox("Suggest from YouTube", settings.RemoteSuggestions, func(checked bool) {
+		settings.RemoteSuggestions = checked
+	})
+	form.AddCheckbox("Radio", settings.Radio, func(checked bool) {
+		settings.Radio = checked
+	})
+	form.AddInputField("Blocked channels", strings.Join(settings.BlockedChannels, ", "), 40, nil, func(text string) {
+		settings.BlockedChannels = splitList(text)
+	})
+	form.AddInputField("Blocked keywords", strings.Join(settings.BlockedKeywords, ", "), 40, nil, func(text string) {
+		settings.BlockedKeywords = splitList(text)
+	})
+	form.AddButton("Save", func() {
+		app.closeDialog()
+		app.settings = settings
+		app.saveSettings()
+		app.updateModeDisplay()
+		app.fillRadio(false)
+	})
+	form.AddButton("Search filters", func() {
+		app.closeDialog()
+		app.showSearchFilters()
+	})
+	form.AddButton("Cancel", app.closeDialog)
+	form.SetCancelFunc(app.closeDialog)
+
+	app.showDialog(form, 64, 15)
+}
+
+// showSearchFilters opens a form for the filters applied to every search
+func (app *App) showSearchFilters() {
+	filters := app.settings.SearchFilters
+	minDuration := formatSpan(filters.MinDuration)
+	maxDuration := formatSpan(filters.MaxDuration)
+	after := filters.UploadedAfter
+	before := filters.UploadedBefore
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Search filters").SetTitleAlign(tview.AlignLeft)
+	form.SetFieldBackgroundColor(tcell.ColorDarkSlateGray)
+	form.AddInputField("Min duration", minDuration, 10, nil, func(text string) {
+		minDuration = text
+	})
+	form.AddInputField("Max duration", maxDuration, 10, nil, func(text string) {
+		maxDuration = text
+	})
+	form.AddInputField("Uploaded after", after, 12, nil, func(text string) {
+		after = text
+	})
+	form.AddInputField("Uploaded before", before, 12, nil, func(text string) {
+		before = text
+	})
+	form.AddInputField("Only channels", strings.Join(filters.Channels, ", "), 40, nil, func(text string) {
+		filters.Channels = splitList(text)
+	})
+	form.AddInputField("Exclude channels", strings.Join(filters.ExcludeChannels, ", "), 40, nil, func(text string) {
+		filters.ExcludeChannels = splitList(text)
+	})
+	form.AddCheckbox("Music only", filters.MusicOnly, func(checked bool) {
+		filters.MusicOnly = checked
+	})
+	form.AddCheckbox("No Shorts", filters.NoShorts, func(checked bool) {
+		filters.NoShorts = checked
+	})
+	form.AddCheckbox("No live streams", filters.NoLive, func(checked bool) {
+		filters.NoLive = checked
+	})
+	form.AddButton("Save", func() {
+		app.closeDialog()
+
+		var err error
+		if filters.MinDuration, err = parseOptionalSpan(minDuration); !app.reportError(err) {
+			return
+		}
+		if filters.MaxDuration, err = parseOptionalSpan(maxDuration); !app.reportError(err) {
+			return
+		}
+		if filters.UploadedAfter, err = services.ParseFilterDate(after); !app.reportError(err) {
+			return
+		}
+		if filters.UploadedBefore, err = services.ParseFilterDate(before); !app.reportError(err) {
+			return
+		}
+
+		app.settings.SearchFilters = filters
+		app.saveSettings()
+		// Search again with the new filters
+		if text := app.search_box.GetText(); text != "" {
+			app.showView("music")
+			app.performSearch(text)
+		}
+	})
+	form.AddButton("Clear", func() {
+		app.closeDialog()
+		app.settings.SearchFilters = models.SearchFilters{}
+		app.saveSettings()
+		if text := app.search_box.GetText(); text != "" {
+			app.showView("music")
+			app.performSearch(text)
+		}
+	})
+	form.AddButton("Cancel", app.closeDialog)
+	form.SetCancelFunc(app.closeDialog)
+
+	app.showDialog(form, 64, 23)
+}
+
+// parseOptionalSpan parses a length of time such as 10m, empty for none
+func parseOptionalSpan(text string) (time.Duration, error) {
+	if strings.TrimSpace(text) == "" {
+		return 0, nil
+	}
+	return services.ParseSpan(strings.TrimSpace(text))
+}
+
+// formatSpan formats a length of time the way parseOptionalSpan reads it
+func formatSpan(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(text string) []string {
+	var items []string
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
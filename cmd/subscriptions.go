@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// subscriptionRefreshInterval is how often the subscribed channels are
+// checked for new uploads
+const subscriptionRefreshInterval = 30 * time.Minute
+
+// buildSubscriptionsView lists the uploads of the subscribed channels that
+// haven't been seen yet
+func (app *App) buildSubscriptionsView() {
+	app.subscriptions_list.SetSelectable(true, false)
+	app.subscriptions_list.SetSelectedFunc(func(row, column int) {
+		if upload, ok := app.selectedUpload(); ok {
+			app.playQueueIndex(app.queue.InsertNext(upload.Video))
+			app.markUploadsSeen(upload.Video)
+		}
+	})
+	app.subscriptions_list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if app.subscriptions == nil {
+			return event
+		}
+		switch event.Rune() {
+		case 'U':
+			app.refreshSubscriptions()
+			return nil
+		case 'X':
+			app.markUploadsSeen(app.unseenVideos()...)
+			return nil
+		case 'L':
+			// Queue every new upload
+			videos := app.unseenVideos()
+			for _, video := range videos {
+				app.queue.Append(video)
+			}
+			app.refreshQueue()
+			app.markUploadsSeen(videos...)
+			return nil
+		}
+
+		upload, ok := app.selectedUpload()
+		if !ok {
+			return event
+		}
+		if app.handleTrackKey(event, upload.Video) {
+			return nil
+		}
+		switch event.Rune() {
+		case 'a':
+			app.enqueue(upload.Video, false)
+			app.markUploadsSeen(upload.Video)
+		case 'A':
+			app.enqueue(upload.Video, true)
+			app.markUploadsSeen(upload.Video)
+		case 'x':
+			app.markUploadsSeen(upload.Video)
+		case 'P':
+			app.addToPlaylist(upload.Video)
+		case 'd':
+			app.showConfirm(fmt.Sprintf("Unsubscribe from %s?", upload.Video.Channel), func() {
+				app.reportError(app.subscriptions.Unsubscribe(upload.Channel))
+				app.updateSubscriptions()
+			})
+		default:
+			return event
+		}
+		return nil
+	})
+
+	app.addView("subscriptions", "Subscriptions", app.subscriptions_list, app.refreshSubscriptionsView, app.subscriptions_list)
+}
+
+// refreshSubscriptionsView redraws the new uploads, the most recently found first
+func (app *App) refreshSubscriptionsView() {
+	row, _ := app.subscriptions_list.GetSelection()
+	app.subscriptions_list.Clear()
+	headers := []string{"Found", "Channel", "Title", "Duration"}
+	for i, header := range headers {
+		app.subscriptions_list.SetCell(0, i, tview.NewTableCell(header).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold))
+	}
+	app.subscriptions_list.SetFixed(1, 0)
+
+	if app.subscriptions == nil {
+		return
+	}
+	uploads := app.subscriptions.Unseen()
+	for i, upload := range uploads {
+		duration := formatTotal(services.ParseDuration(upload.Video.Duration))
+		app.subscriptions_list.SetCell(i+1, 0, tview.NewTableCell(upload.FoundAt.Format("2006-01-02 15:04")).SetReference(upload))
+		app.subscriptions_list.SetCell(i+1, 1, tview.NewTableCell(upload.Video.Channel).SetMaxWidth(15))
+		app.subscriptions_list.SetCell(i+1, 2, tview.NewTableCell(upload.Video.Title).SetMaxWidth(40))
+		app.subscriptions_list.SetCell(i+1, 3, tview.NewTableCell(duration))
+	}
+
+	if len(uploads) > 0 {
+		app.subscriptions_list.Select(max(1, min(row, len(uploads))), 0)
+		return
+	}
+	message := "No new uploads"
+	if len(app.subscriptions.Subscriptions()) == 0 {
+		message = "No subscriptions yet, press u in a channel to subscribe to it"
+	}
+	app.subscriptions_list.SetCell(1, 0, tview.NewTableCell(message).SetSelectable(false).SetTextColor(tcell.ColorGray))
+}
+
+func (app *App) selectedUpload() (models.Upload, bool) {
+	row, _ := app.subscriptions_list.GetSelection()
+	if row <= 0 {
+		return models.Upload{}, false
+	}
+	upload, ok := app.subscriptions_list.GetCell(row, 0).GetReference().(models.Upload)
+	return upload, ok
+}
+
+func (app *App) unseenVideos() []models.Video {
+	var videos []models.Video
+	for _, upload := range app.subscriptions.Unseen() {
+		videos = append(videos, upload.Video)
+	}
+	return videos
+}
+
+// markUploadsSeen records that videos have been seen, in case they are new
+// uploads of subscribed channels
+func (app *App) markUploadsSeen(videos ...models.Video) {
+	if app.subscriptions == nil || len(videos) == 0 {
+		return
+	}
+	ids := make([]string, len(videos))
+	for i, video := range videos {
+		ids[i] = video.ID
+	}
+	if err := app.subscriptions.MarkSeen(ids...); err != nil {
+		log.Printf("Error saving subscriptions: %v", err)
+	}
+	app.updateSubscriptions()
+}
+
+// updateSubscriptions shows the number of new uploads in the Menu and
+// redraws the subscriptions view if it is showing
+func (app *App) updateSubscriptions() {
+	if app.subscriptions == nil {
+		return
+	}
+	text := "Subscriptions"
+	if count := app.subscriptions.UnseenCount(); count > 0 {
+		text = fmt.Sprintf("Subscriptions (%d)", count)
+	}
+	app.menu.SetItemText(app.subscriptions_item, text, "")
+	if app.current_view == "subscriptions" {
+		app.refreshSubscriptionsView()
+	}
+}
+
+// watchSubscriptions checks the subscribed channels for new uploads now
+// and then every subscriptionRefreshInterval until the application exits
+func (app *App) watchSubscriptions() {
+	if app.subscriptions == nil {
+		return
+	}
+	app.subscriptions_ctx, app.subscriptions_cancel = context.WithCancel(context.Background())
+	app.updateSubscriptions()
+	app.refreshSubscriptions()
+
+	ctx := app.subscriptions_ctx
+	go func() {
+		ticker := time.NewTicker(subscriptionRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.app.QueueUpdateDraw(app.refreshSubscriptions)
+			}
+		}
+	}()
+}
+
+// refreshSubscriptions checks the subscribed channels for new uploads in
+// the background, unless a check is already running
+func (app *App) refreshSubscriptions() {
+	if app.subscriptions == nil || app.subscriptions_ctx == nil {
+		return
+	}
+	if app.subscriptions_loading {
+		app.subscriptions_pending = true
+		return
+	}
+	app.subscriptions_loading = true
+	if app.current_view == "subscriptions" {
+		app.view_box.SetTitle("Subscriptions (checking for new uploads...)")
+	}
+
+	ctx := app.subscriptions_ctx
+	go func() {
+		added, err := services.RefreshSubscriptions(ctx, app.subscriptions)
+		if ctx.Err() != nil {
+			return
+		}
+		app.app.QueueUpdateDraw(func() {
+			app.subscriptions_loading = false
+			if err != nil {
+				log.Printf("Error refreshing subscriptions: %v", err)
+			}
+			if added > 0 {
+				log.Printf("Found %d new uploads", added)
+			}
+			if app.current_view == "subscriptions" {
+				app.view_box.SetTitle(app.views["subscriptions"].title)
+			}
+			app.updateSubscriptions()
+			if app.subscriptions_pending {
+				app.subscriptions_pending = false
+				app.refreshSubscriptions()
+			}
+		})
+	}()
+}
+
+// toggleSubscription subscribes to the channel that is open, or offers to
+// unsubscribe if it already is
+func (app *App) toggleSubscription() {
+	if app.subscriptions == nil || app.channel == nil {
+		return
+	}
+	channel := *app.channel
+	if app.subscriptions.Subscribed(channel.URL) {
+		app.showConfirm(fmt.Sprintf("Unsubscribe from %s?", channel.Name), func() {
+			app.reportError(app.subscriptions.Unsubscribe(channel.URL))
+			app.showChannelInfo()
+			app.updateSubscriptions()
+		})
+		return
+	}
+
+	if !app.reportError(app.subscriptions.Subscribe(channel)) {
+		return
+	}
+	app.showChannelInfo()
+	// The first check takes note of the uploads there are so far
+	app.refreshSubscriptions()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/models"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+const (
+	suggestLimit      = 10 // suggestions shown under the search box
+	suggestLocalLimit = 5  // of which at most this many come from the local store
+	suggestMinLength  = 2  // characters typed before anything is suggested
+)
+
+// setupSuggestions completes what is typed into the search box with past
+// searches, the titles and channels of the tracks stored locally and, if
+// enabled in Settings, the suggestions of YouTube
+func (app *App) setupSuggestions() {
+	remote := services.NewYouTubeSuggestions(func(prefix string) {
+		app.app.QueueUpdateDraw(func() {
+			// Only update the drop-down if the text hasn't moved on
+			text := strings.ToLower(strings.TrimSpace(app.search_box.GetText()))
+			if text == prefix && app.search_box.HasFocus() {
+				app.search_box.Autocomplete()
+			}
+		})
+	})
+
+	app.suggester = services.NewSuggester(suggestLimit,
+		services.LimitSuggestions(services.ListSuggestions(app.pastSearches), suggestLocalLimit),
+		services.LimitSuggestions(services.ListSuggestions(app.localTrackNames), suggestLocalLimit),
+		services.SuggestFunc(func(prefix string) []string {
+			if !app.settings.RemoteSuggestions {
+				return nil
+			}
+			return remote.Suggestions(prefix)
+		}),
+	)
+
+	app.search_box.SetAutocompleteFunc(app.searchSuggestions)
+	app.search_box.SetAutocompletedFunc(func(text string, index, source int) bool {
+		switch source {
+		case tview.AutocompletedEnter:
+			app.suggest_open = false
+			app.search_box.SetText(text)
+			app.submitSearch(text)
+			return true
+		case tview.AutocompletedTab, tview.AutocompletedClick:
+			app.suggest_open = false
+			app.search_box.SetText(text)
+			return true
+		}
+		return false
+	})
+}
+
+// searchSuggestions returns the entries of the search box drop-down. The
+// first is the text itself, so that Enter searches for what was typed
+// unless another entry is picked.
+func (app *App) searchSuggestions(text string) []string {
+	app.suggest_open = false
+	// Playlist URLs and queries with filters aren't completed
+	if len(strings.TrimSpace(text)) < suggestMinLength || strings.Contains(text, ":") || app.suggester == nil {
+		return nil
+	}
+	suggestions := app.suggester.Suggestions(text)
+	if len(suggestions) == 0 {
+		return nil
+	}
+	app.suggest_open = true
+	return append([]string{text}, suggestions...)
+}
+
+// localTrackNames returns the titles and channels of the tracks in the
+// history, library and playlists, most recently played first
+func (app *App) localTrackNames() []string {
+	var names []string
+	add := func(video models.Video) {
+		names = append(names, video.Title)
+		if video.Channel != "" {
+			names = append(names, video.Channel)
+		}
+	}
+
+	if app.history != nil {
+		entries := app.history.Entries()
+		for i := len(entries) - 1; i >= 0; i-- {
+			add(entries[i].Video)
+		}
+	}
+	if app.library != nil {
+		for _, track := range app.library.Tracks() {
+			add(track.Video)
+		}
+	}
+	if app.playlists != nil {
+		for _, playlist := range app.playlists.Playlists() {
+			for _, entry := range playlist.Entries {
+				add(entry.Video)
+			}
+		}
+	}
+	return names
+}
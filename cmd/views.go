@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/rivo/tview"
+)
+
+// view is a page of the content pane, e.g. the music list or the playlists
+type view struct {
+	title string
+	// focus lists the primitives Tab moves between while the view is shown
+	focus []tview.Primitive
+	// show is called every time the view is switched to
+	show func()
+}
+
+// addView registers a page of the content pane
+func (app *App) addView(name, title string, page tview.Primitive, show func(), focus ...tview.Primitive) {
+	app.views[name] = &view{title: title, focus: focus, show: show}
+	app.pages.AddPage(name, page, true, false)
+}
+
+// showView switches the content pane to the named view and focuses it
+func (app *App) showView(name string) {
+	view, ok := app.views[name]
+	if !ok {
+		return
+	}
+
+	app.current_view = name
+	app.view_box.SetTitle(view.title)
+	app.pages.SwitchToPage(name)
+	if view.show != nil {
+		view.show()
+	}
+	if len(view.focus) > 0 {
+		app.app.SetFocus(view.focus[0])
+	}
+}
+
+// focusOrder returns the primitives Tab cycles through
+func (app *App) focusOrder() []tview.Primitive {
+	order := []tview.Primitive{app.search_box}
+	if view, ok := app.views[app.current_view]; ok {
+		order = append(order, view.focus...)
+	}
+	return append(order, app.queue_list, app.menu)
+}
+
+// cycleFocus moves the focus to the next pane
+func (app *App) cycleFocus(reverse bool) {
+	order := app.focusOrder()
+	focused := app.app.GetFocus()
+	for i, primitive := range order {
+		// A view can list a container, e.g. pages, that holds the focus
+		if primitive != focused && !primitive.HasFocus() {
+			continue
+		}
+		step := 1
+		if reverse {
+			step = len(order) - 1
+		}
+		app.app.SetFocus(order[(i+step)%len(order)])
+		return
+	}
+	app.app.SetFocus(order[0])
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Channel is a YouTube channel
+type Channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`    // e.g. https://www.youtube.com/@handle
+	Handle      string `json:"handle"` // e.g. @handle
+	Description string `json:"description,omitempty"`
+	Subscribers int    `json:"subscribers,omitempty"`
+}
+
+// ChannelPlaylist is a playlist made by a channel
+type ChannelPlaylist struct {
+	ID    string
+	Title string
+}
+
+// Subscription is a channel whose new uploads are followed
+type Subscription struct {
+	Channel      Channel   `json:"channel"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+	CheckedAt    time.Time `json:"checked_at,omitempty"` // zero until the first refresh
+}
+
+// Upload is a video of a subscribed channel found by a refresh
+type Upload struct {
+	Video   Video     `json:"video"`
+	Channel string    `json:"channel"` // URL of the subscribed channel
+	FoundAt time.Time `json:"found_at"`
+	Seen    bool      `json:"seen"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// HistoryOutcome tells how playback of a history entry ended
+type HistoryOutcome string
+
+const (
+	HistoryPlaying  HistoryOutcome = ""         // still playing
+	HistoryFinished HistoryOutcome = "finished" // played to the end
+	HistorySkipped  HistoryOutcome = "skipped"  // stopped or replaced before the end
+	HistoryFailed   HistoryOutcome = "failed"   // the player gave up with an error
+)
+
+type HistoryEntry struct {
+	Video     Video          `json:"video"`
+	StartedAt time.Time      `json:"started_at"`
+	Listened  time.Duration  `json:"listened"` // time spent playing, pauses excluded
+	Outcome   HistoryOutcome `json:"outcome"`
+}
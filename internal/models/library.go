@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// TrackInfo is what the user has recorded about a track
+type TrackInfo struct {
+	Video     Video     `json:"video"`
+	Favorite  bool      `json:"favorite"`
+	Rating    int       `json:"rating,omitempty"` // 1 to 5, 0 if not rated
+	Tags      []string  `json:"tags,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Empty reports whether nothing is recorded about the track
+func (t TrackInfo) Empty() bool {
+	return !t.Favorite && t.Rating == 0 && len(t.Tags) == 0 && t.Notes == ""
+}
+
+// HasTags reports whether the track has all of the given tags
+func (t TrackInfo) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range t.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
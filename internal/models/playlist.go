@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type PlaylistEntry struct {
+	Video   Video     `json:"video"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type Playlist struct {
+	Name      string          `json:"name"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entries   []PlaylistEntry `json:"entries"`
+	// Query holds the rules of a smart playlist, whose tracks are worked
+	// out from the play history and library whenever it is opened
+	Query string `json:"query,omitempty"`
+}
+
+// Smart reports whether the playlist is defined by rules rather than a list of tracks
+func (p Playlist) Smart() bool {
+	return p.Query != ""
+}
+
+// Videos returns the videos in the playlist in order
+func (p Playlist) Videos() []Video {
+	videos := make([]Video, len(p.Entries))
+	for i, entry := range p.Entries {
+		videos[i] = entry.Video
+	}
+	return videos
+}
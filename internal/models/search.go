@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// SearchFilters narrow down search results. Zero values don't filter.
+type SearchFilters struct {
+	MinDuration     time.Duration `json:"min_duration,omitempty"`
+	MaxDuration     time.Duration `json:"max_duration,omitempty"`
+	UploadedAfter   string        `json:"uploaded_after,omitempty"` // YYYY-MM-DD
+	UploadedBefore  string        `json:"uploaded_before,omitempty"`
+	Channels        []string      `json:"channels,omitempty"` // only results from these channels
+	ExcludeChannels []string      `json:"exclude_channels,omitempty"`
+	MusicOnly       bool          `json:"music_only,omitempty"`
+	NoShorts        bool          `json:"no_shorts,omitempty"`
+	NoLive          bool          `json:"no_live,omitempty"`
+}
+
+// Empty reports whether the filters let every result through
+func (f SearchFilters) Empty() bool {
+	return f.MinDuration == 0 && f.MaxDuration == 0 &&
+		f.UploadedAfter == "" && f.UploadedBefore == "" &&
+		len(f.Channels) == 0 && len(f.ExcludeChannels) == 0 &&
+		!f.MusicOnly && !f.NoShorts && !f.NoLive
+}
+
+// SavedSearch is a query kept to be run again on demand. It remembers the
+// results it has shown so that the ones that are new stand out.
+type SavedSearch struct {
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"created_at"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	Seen      []string  `json:"seen,omitempty"` // ids of the results shown so far, oldest first
+}
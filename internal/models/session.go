@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// What the music list can be showing when a session is saved
+const (
+	MusicTrending = "trending"
+	MusicSearch   = "search"
+	MusicPlaylist = "playlist" // a YouTube playlist or mix
+)
+
+// Session is the state ytview was in when it last exited
+type Session struct {
+	Queue    []Video       `json:"queue"`
+	Current  int           `json:"current"`         // index of the current track in Queue, -1 if none
+	Position time.Duration `json:"position"`        // playback position in the current track
+	Ended    bool          `json:"ended,omitempty"` // the current track had played to the end
+	View     string        `json:"view"`
+	Search   string        `json:"search"`             // text in the search box
+	Music    string        `json:"music,omitempty"`    // what the music list showed, MusicSearch, MusicPlaylist or MusicTrending
+	MusicID  string        `json:"music_id,omitempty"` // the search or playlist id the music list showed
+	SavedAt  time.Time     `json:"saved_at"`
+}
+
+// Resumable reports whether there is anything worth offering to resume
+func (s Session) Resumable() bool {
+	return len(s.Queue) > 0 || s.Search != "" || s.MusicID != ""
+}
+
+// CurrentTrack returns the track that was playing
+func (s Session) CurrentTrack() (Video, bool) {
+	if s.Current < 0 || s.Current >= len(s.Queue) {
+		return Video{}, false
+	}
+	return s.Queue[s.Current], true
+}
@@ -0,0 +1,28 @@
+package models
+
+// RepeatMode controls what the queue does after the last track
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = "off"
+	RepeatOne RepeatMode = "one"
+	RepeatAll RepeatMode = "all"
+)
+
+type Settings struct {
+	Volume  int        `json:"volume"`
+	Muted   bool       `json:"muted"`
+	Repeat  RepeatMode `json:"repeat"`
+	Shuffle bool       `json:"shuffle"`
+	// PageSize is how many search or trending results are loaded at a time
+	PageSize int `json:"page_size"`
+	// SearchFilters apply to every search, on top of the filters in the query
+	SearchFilters SearchFilters `json:"search_filters"`
+	// RemoteSuggestions completes searches with the suggestions of YouTube
+	RemoteSuggestions bool `json:"remote_suggestions"`
+
+	// Radio keeps the queue filled with tracks related to the current one
+	Radio           bool     `json:"radio"`
+	BlockedChannels []string `json:"blocked_channels,omitempty"`
+	BlockedKeywords []string `json:"blocked_keywords,omitempty"`
+}
@@ -1,12 +1,15 @@
 package models
 
 type YtDlpVideoResponse struct {
-	ID        string  	`json:"id"`
-	Title     string  	`json:"title"`
-	Duration  float64 	`json:"duration"`
-	Views     int     	`json:"view_count"`
-	Channel   string  	`json:"channel"`
-	Thumbnail string  	`json:"thumbnail"`
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Duration    float64 `json:"duration"`
+	Views       int     `json:"view_count"`
+	Channel     string  `json:"channel"`
+	Thumbnail   string  `json:"thumbnail"`
+	ChannelID   string  `json:"channel_id"`
+	ChannelURL  string  `json:"channel_url"`
+	UploaderURL string  `json:"uploader_url"`
 }
 
 type YtDlpTrendingMusicResponse struct {
@@ -14,3 +17,27 @@ type YtDlpTrendingMusicResponse struct {
 	Title   string               `json:"title"`
 	Entries []YtDlpVideoResponse `json:"entries"`
 }
+
+type YtDlpPlaylistResponse struct {
+	ID      string               `json:"id"`
+	Title   string               `json:"title"`
+	Channel string               `json:"channel"`
+	Entries []YtDlpVideoResponse `json:"entries"`
+}
+
+// YtDlpChannelResponse is what yt-dlp prints for a tab of a channel
+type YtDlpChannelResponse struct {
+	ChannelID   string `json:"channel_id"`
+	Channel     string `json:"channel"`
+	ChannelURL  string `json:"channel_url"`
+	UploaderID  string `json:"uploader_id"` // the channel's @handle
+	UploaderURL string `json:"uploader_url"`
+	Description string `json:"description"`
+	Followers   int    `json:"channel_follower_count"`
+}
+
+// YtDlpChannelPlaylistResponse is an entry of the playlists tab of a channel
+type YtDlpChannelPlaylistResponse struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
@@ -7,6 +7,8 @@ type Video struct {
 	Duration  string `json:"duration"`
 	ID        string `json:"id"`
 	Thumbnail string `json:"thumb"`
+	// ChannelURL is the page of the channel that uploaded the video, if known
+	ChannelURL string `json:"channel_url,omitempty"`
 }
 
 type YoutubeVideoDetailResponse struct {
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// MaxHistoryEntries is how many plays the history keeps before dropping the oldest
+const MaxHistoryEntries = 5000
+
+// HistoryStore records every track that is played in a JSON file in the
+// data directory. The entry of the track that is playing stays open until
+// Finish is called.
+type HistoryStore struct {
+	mu      sync.Mutex
+	path    string
+	entries []models.HistoryEntry
+	open    bool
+}
+
+func LoadHistoryStore() (*HistoryStore, error) {
+	path, err := dataPath("history.json")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &HistoryStore{path: path}
+	if err := readJSON(path, &store.entries); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// A track that was playing when ytview last exited without closing its
+	// entry didn't make it to the end
+	for i := range store.entries {
+		if store.entries[i].Outcome == models.HistoryPlaying {
+			store.entries[i].Outcome = models.HistorySkipped
+		}
+	}
+	return store, nil
+}
+
+// Entries returns the history, oldest first
+func (s *HistoryStore) Entries() []models.HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.HistoryEntry(nil), s.entries...)
+}
+
+// Start opens an entry for a track that has just started playing. Finish
+// should be called for the previous track first, an entry that is still open
+// is closed as skipped.
+func (s *HistoryStore) Start(video models.Video) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.open {
+		s.close(s.entries[len(s.entries)-1].Listened, models.HistorySkipped)
+	}
+	s.entries = append(s.entries, models.HistoryEntry{
+		Video:     video,
+		StartedAt: time.Now(),
+	})
+	if len(s.entries) > MaxHistoryEntries {
+		s.entries = s.entries[len(s.entries)-MaxHistoryEntries:]
+	}
+	s.open = true
+	return s.save()
+}
+
+// Finish closes the open entry, if any, with the time listened and how
+// playback ended
+func (s *HistoryStore) Finish(listened time.Duration, outcome models.HistoryOutcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.close(listened, outcome) {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *HistoryStore) close(listened time.Duration, outcome models.HistoryOutcome) bool {
+	if !s.open || len(s.entries) == 0 {
+		return false
+	}
+	entry := &s.entries[len(s.entries)-1]
+	entry.Listened = listened.Round(time.Second)
+	entry.Outcome = outcome
+	s.open = false
+	return true
+}
+
+// Clear forgets the whole history, except the entry of the track that is playing
+func (s *HistoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.open && len(s.entries) > 0 {
+		s.entries = s.entries[len(s.entries)-1:]
+	} else {
+		s.entries = nil
+	}
+	return s.save()
+}
+
+func (s *HistoryStore) save() error {
+	return writeJSON(s.path, s.entries)
+}
+
+// ExportHistory writes entries to path as CSV or JSON, depending on its extension
+func ExportHistory(path string, entries []models.HistoryEntry) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".json" {
+		return fmt.Errorf("unsupported history format %q, use .csv or .json", filepath.Ext(path))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if ext == ".json" {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	} else {
+		err = writeHistoryCSV(file, entries)
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func writeHistoryCSV(file *os.File, entries []models.HistoryEntry) error {
+	w := csv.NewWriter(file)
+	w.Write([]string{"started_at", "id", "title", "channel", "duration", "listened_seconds", "outcome", "url"})
+	for _, entry := range entries {
+		w.Write([]string{
+			entry.StartedAt.Format(time.RFC3339),
+			entry.Video.ID,
+			entry.Video.Title,
+			entry.Video.Channel,
+			entry.Video.Duration,
+			strconv.Itoa(int(entry.Listened.Seconds())),
+			string(entry.Outcome),
+			VideoURL(entry.Video.ID),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
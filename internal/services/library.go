@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// LibraryStore keeps favorites, ratings, tags and notes, keyed by video id,
+// in a JSON file in the data directory. Every change is written to disk
+// straight away.
+type LibraryStore struct {
+	path   string
+	tracks map[string]models.TrackInfo
+}
+
+func LoadLibraryStore() (*LibraryStore, error) {
+	path, err := dataPath("library.json")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &LibraryStore{path: path, tracks: make(map[string]models.TrackInfo)}
+	if err := readJSON(path, &store.tracks); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get returns what is recorded about a video
+func (s *LibraryStore) Get(id string) models.TrackInfo {
+	return s.tracks[id]
+}
+
+// Tracks returns the tracks that have all of the given tags, most recently
+// changed first
+func (s *LibraryStore) Tracks(tags ...string) []models.TrackInfo {
+	var tracks []models.TrackInfo
+	for _, track := range s.tracks {
+		if track.HasTags(tags...) {
+			tracks = append(tracks, track)
+		}
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].UpdatedAt.After(tracks[j].UpdatedAt)
+	})
+	return tracks
+}
+
+// Tags returns every tag in use, in alphabetical order
+func (s *LibraryStore) Tags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, track := range s.tracks {
+		for _, tag := range track.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// ToggleFavorite marks a video as a favorite, or unmarks it. It returns
+// whether the video is now a favorite.
+func (s *LibraryStore) ToggleFavorite(video models.Video) (bool, error) {
+	favorite := !s.tracks[video.ID].Favorite
+	return favorite, s.update(video, func(track *models.TrackInfo) {
+		track.Favorite = favorite
+	})
+}
+
+// SetRating rates a video from 1 to 5 stars, 0 removes the rating
+func (s *LibraryStore) SetRating(video models.Video, rating int) error {
+	if rating < 0 || rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	return s.update(video, func(track *models.TrackInfo) {
+		track.Rating = rating
+	})
+}
+
+// SetTags replaces the tags of a video
+func (s *LibraryStore) SetTags(video models.Video, tags []string) error {
+	return s.update(video, func(track *models.TrackInfo) {
+		track.Tags = NormalizeTags(tags)
+	})
+}
+
+func (s *LibraryStore) SetNotes(video models.Video, notes string) error {
+	return s.update(video, func(track *models.TrackInfo) {
+		track.Notes = strings.TrimSpace(notes)
+	})
+}
+
+// update changes the info of a video, forgetting the video once nothing is
+// recorded about it anymore
+func (s *LibraryStore) update(video models.Video, change func(track *models.TrackInfo)) error {
+	track := s.tracks[video.ID]
+	track.Video = video
+	change(&track)
+	track.UpdatedAt = time.Now()
+
+	if track.Empty() {
+		delete(s.tracks, video.ID)
+	} else {
+		s.tracks[video.ID] = track
+	}
+	return s.save()
+}
+
+func (s *LibraryStore) save() error {
+	return writeJSON(s.path, s.tracks)
+}
+
+// NormalizeTags lower-cases tags and drops empty and repeated ones. Tags
+// may be given as a single comma or space separated string.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		for _, field := range strings.FieldsFunc(tag, func(r rune) bool {
+			return r == ',' || r == ' '
+		}) {
+			field = strings.ToLower(field)
+			if !seen[field] {
+				seen[field] = true
+				normalized = append(normalized, field)
+			}
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	mpvConnectTimeout = 3 * time.Second
+	mpvCommandTimeout = 2 * time.Second
+)
+
+// Property observer ids used with mpv's observe_property command
+const (
+	mpvObservePause = iota + 1
+	mpvObserveEof
+)
+
+func lookupMpv() (string, bool) {
+	return lookupFirst(
+		"mpv",
+		"/Applications/mpv.app/Contents/MacOS/mpv",
+		filepath.Join(os.Getenv("ProgramFiles"), "mpv", "mpv.exe"),
+	)
+}
+
+// mpvMessage is either a reply to a command or an asynchronous event sent over mpv's IPC socket
+type mpvMessage struct {
+	RequestID int             `json:"request_id"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	Event     string          `json:"event"`
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+}
+
+// mpvConn is a connection to one mpv process and the commands waiting for
+// a reply on it. Each track gets its own, so that a connection being closed
+// only fails its own commands.
+type mpvConn struct {
+	io.ReadWriteCloser
+	pending map[int]chan mpvMessage
+}
+
+// MpvPlayer plays media with mpv and controls it over mpv's JSON IPC socket
+type MpvPlayer struct {
+	processPlayer
+	path string
+
+	ipcMu  sync.Mutex // guards conn, nextID and the pending commands of every connection
+	conn   *mpvConn
+	nextID int
+}
+
+func NewMpvPlayer(path string) *MpvPlayer {
+	return &MpvPlayer{
+		processPlayer: newProcessPlayer(),
+		path:          path,
+	}
+}
+
+func (p *MpvPlayer) Name() string {
+	return "mpv"
+}
+
+func (p *MpvPlayer) Play(url string) error {
+	p.Stop()
+
+	socket := mpvSocketPath()
+	if runtime.GOOS != "windows" {
+		os.Remove(socket)
+	}
+
+	args := []string{
+		"--no-video",
+		"--no-terminal",
+		"--idle=no",
+		"--input-ipc-server=" + socket,
+	}
+	if volume := p.startVolume(); volume >= 0 {
+		args = append(args, fmt.Sprintf("--volume=%d", volume))
+	}
+	cmd := exec.Command(p.path, append(args, url)...)
+	if err := p.start(cmd, url); err != nil {
+		return err
+	}
+
+	go p.connect(cmd, socket, mpvConnectTimeout)
+	return nil
+}
+
+// connect waits for the mpv started by cmd to open its IPC socket and
+// observes its playback. mpv sends the current value of an observed
+// property straight away, which moves the player out of the loading state.
+func (p *MpvPlayer) connect(cmd *exec.Cmd, socket string, timeout time.Duration) {
+	socketConn, err := dialMpv(socket, timeout)
+	if err != nil {
+		// Playback still works, it just can't be controlled
+		log.Printf("Error connecting to mpv IPC socket: %v", err)
+		if p.runs(cmd) {
+			p.transitionFrom(PlayerLoading, PlayerPlaying, nil)
+		}
+		return
+	}
+
+	conn := &mpvConn{ReadWriteCloser: socketConn, pending: make(map[int]chan mpvMessage)}
+	p.ipcMu.Lock()
+	// The track may have been stopped or replaced while mpv started up
+	if !p.runs(cmd) {
+		p.ipcMu.Unlock()
+		conn.Close()
+		return
+	}
+	previous := p.conn
+	p.conn = conn
+	p.ipcMu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+	go p.readLoop(conn)
+
+	_, pauseErr := p.command("observe_property", mpvObservePause, "pause")
+	p.command("observe_property", mpvObserveEof, "eof-reached")
+
+	// Don't leave the player loading forever if mpv can't be observed
+	if pauseErr != nil && p.runs(cmd) {
+		p.transitionFrom(PlayerLoading, PlayerPlaying, nil)
+	}
+}
+
+func (p *MpvPlayer) Pause() error {
+	if !p.running() {
+		return fmt.Errorf("no media is playing")
+	}
+	if _, err := p.command("set_property", "pause", true); err != nil {
+		return err
+	}
+	p.transition(PlayerPaused, nil)
+	return nil
+}
+
+func (p *MpvPlayer) Resume() error {
+	if !p.running() || p.State() != PlayerPaused {
+		return fmt.Errorf("no paused media to resume")
+	}
+	if _, err := p.command("set_property", "pause", false); err != nil {
+		return err
+	}
+	p.transition(PlayerPlaying, nil)
+	return nil
+}
+
+func (p *MpvPlayer) Stop() error {
+	if !p.running() {
+		return nil
+	}
+	p.closeConn()
+	p.kill()
+	return nil
+}
+
+func (p *MpvPlayer) Seek(pos time.Duration) error {
+	_, err := p.command("seek", pos.Seconds(), "absolute")
+	return err
+}
+
+func (p *MpvPlayer) Position() (time.Duration, error) {
+	return p.getSeconds("time-pos")
+}
+
+func (p *MpvPlayer) Duration() (time.Duration, error) {
+	return p.getSeconds("duration")
+}
+
+func (p *MpvPlayer) Volume() (int, error) {
+	if !p.running() {
+		if volume := p.startVolume(); volume >= 0 {
+			return volume, nil
+		}
+	}
+	data, err := p.command("get_property", "volume")
+	if err != nil {
+		return 0, err
+	}
+	var volume float64
+	if err := json.Unmarshal(data, &volume); err != nil {
+		return 0, err
+	}
+	return int(volume), nil
+}
+
+func (p *MpvPlayer) SetVolume(volume int) error {
+	volume = p.rememberVolume(volume)
+	if !p.running() {
+		return nil
+	}
+	_, err := p.command("set_property", "volume", volume)
+	return err
+}
+
+func (p *MpvPlayer) getSeconds(property string) (time.Duration, error) {
+	data, err := p.command("get_property", property)
+	if err != nil {
+		return 0, err
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// command sends a command to mpv and waits for its reply
+func (p *MpvPlayer) command(args ...interface{}) (json.RawMessage, error) {
+	p.ipcMu.Lock()
+	conn := p.conn
+	if conn == nil {
+		p.ipcMu.Unlock()
+		return nil, fmt.Errorf("mpv is not running")
+	}
+	p.nextID++
+	id := p.nextID
+	reply := make(chan mpvMessage, 1)
+	conn.pending[id] = reply
+
+	request, _ := json.Marshal(map[string]interface{}{
+		"command":    args,
+		"request_id": id,
+	})
+	_, err := conn.Write(append(request, '\n'))
+	p.ipcMu.Unlock()
+
+	if err != nil {
+		p.forget(conn, id)
+		return nil, err
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return nil, fmt.Errorf("mpv connection closed")
+		}
+		if msg.Error != "success" {
+			return nil, fmt.Errorf("mpv: %s", msg.Error)
+		}
+		return msg.Data, nil
+	case <-time.After(mpvCommandTimeout):
+		p.forget(conn, id)
+		return nil, fmt.Errorf("mpv: timed out waiting for reply")
+	}
+}
+
+func (p *MpvPlayer) forget(conn *mpvConn, id int) {
+	p.ipcMu.Lock()
+	delete(conn.pending, id)
+	p.ipcMu.Unlock()
+}
+
+// current reports whether conn is the connection to the mpv that is playing
+func (p *MpvPlayer) current(conn *mpvConn) bool {
+	p.ipcMu.Lock()
+	defer p.ipcMu.Unlock()
+	return p.conn == conn
+}
+
+// readLoop dispatches replies and events until the connection is closed
+func (p *MpvPlayer) readLoop(conn *mpvConn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var msg mpvMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		if msg.Event != "" {
+			// Events still buffered from a previous track don't apply to this one
+			if p.current(conn) {
+				p.handleEvent(msg)
+			}
+			continue
+		}
+
+		p.ipcMu.Lock()
+		reply, ok := conn.pending[msg.RequestID]
+		delete(conn.pending, msg.RequestID)
+		p.ipcMu.Unlock()
+		if ok {
+			reply <- msg
+		}
+	}
+
+	p.ipcMu.Lock()
+	if p.conn == conn {
+		p.conn = nil
+	}
+	for id, reply := range conn.pending {
+		close(reply)
+		delete(conn.pending, id)
+	}
+	p.ipcMu.Unlock()
+}
+
+func (p *MpvPlayer) handleEvent(msg mpvMessage) {
+	if msg.Event != "property-change" {
+		return
+	}
+
+	var value bool
+	if err := json.Unmarshal(msg.Data, &value); err != nil {
+		return
+	}
+
+	switch msg.ID {
+	case mpvObservePause:
+		if !p.running() {
+			return
+		}
+		if value {
+			p.transition(PlayerPaused, nil)
+		} else {
+			p.transition(PlayerPlaying, nil)
+		}
+	case mpvObserveEof:
+		if value {
+			p.transition(PlayerEnded, nil)
+		}
+	}
+}
+
+func (p *MpvPlayer) closeConn() {
+	p.ipcMu.Lock()
+	conn := p.conn
+	p.conn = nil
+	p.ipcMu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// mpvSocketPath returns the IPC endpoint for this ytview process
+func mpvSocketPath() string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`\\.\pipe\ytview-mpv-%d`, os.Getpid())
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ytview-mpv-%d.sock", os.Getpid()))
+}
+
+// dialMpv connects to mpv's IPC endpoint, waiting for mpv to create it
+func dialMpv(socket string, timeout time.Duration) (io.ReadWriteCloser, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var conn io.ReadWriteCloser
+		var err error
+		if runtime.GOOS == "windows" {
+			conn, err = os.OpenFile(socket, os.O_RDWR, 0)
+		} else {
+			conn, err = net.Dial("unix", socket)
+		}
+		if err == nil {
+			return conn, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// serveTestMpv answers every command sent to a stub IPC socket with success
+// and sends pause once release is closed
+func serveTestMpv(t *testing.T, release chan struct{}) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub IPC socket is a unix socket")
+	}
+	socket := filepath.Join(t.TempDir(), "mpv.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		go func() {
+			<-release
+			fmt.Fprintf(conn, `{"event":"property-change","id":%d,"name":"pause","data":false}`+"\n", mpvObservePause)
+		}()
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var request struct {
+				RequestID int `json:"request_id"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &request) == nil {
+				fmt.Fprintf(conn, `{"request_id":%d,"error":"success"}`+"\n", request.RequestID)
+			}
+		}
+	}()
+	return socket
+}
+
+func TestMpvLoadingUntilPauseObserved(t *testing.T) {
+	release := make(chan struct{})
+	socket := serveTestMpv(t, release)
+
+	p := NewMpvPlayer("mpv")
+	cmd := fakePlayerCommand("wait")
+	if err := p.start(cmd, "track"); err != nil {
+		t.Fatal(err)
+	}
+	go p.connect(cmd, socket, time.Second)
+
+	// Connecting and observing doesn't say playback has started
+	expectEvents(t, p.playerStateMachine, PlayerLoading)
+	expectNoEvent(t, p.playerStateMachine)
+
+	close(release)
+	expectEvents(t, p.playerStateMachine, PlayerPlaying)
+	p.Stop()
+	expectEvents(t, p.playerStateMachine, PlayerIdle)
+}
+
+func TestMpvNoSocket(t *testing.T) {
+	p := NewMpvPlayer("mpv")
+	cmd := fakePlayerCommand("wait")
+	if err := p.start(cmd, "track"); err != nil {
+		t.Fatal(err)
+	}
+	p.connect(cmd, filepath.Join(t.TempDir(), "missing.sock"), 100*time.Millisecond)
+
+	// Playback can't be controlled, but it isn't left loading
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying)
+	p.Stop()
+	expectEvents(t, p.playerStateMachine, PlayerIdle)
+}
+
+func TestMpvReplacedWhileConnecting(t *testing.T) {
+	socket := serveTestMpv(t, make(chan struct{}))
+
+	p := NewMpvPlayer("mpv")
+	cmd := fakePlayerCommand("wait")
+	if err := p.start(cmd, "track"); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+	p.connect(cmd, socket, time.Second)
+
+	// The stopped track's connection isn't kept
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerIdle)
+	expectNoEvent(t, p.playerStateMachine)
+	if _, err := p.command("get_property", "volume"); err == nil {
+		t.Error("command succeeded on a connection to a stopped track")
+	}
+}
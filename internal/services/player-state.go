@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PlayerState describes what a player backend is currently doing
+type PlayerState string
+
+const (
+	PlayerIdle    PlayerState = "idle"
+	PlayerLoading PlayerState = "loading"
+	PlayerPlaying PlayerState = "playing"
+	PlayerPaused  PlayerState = "paused"
+	PlayerEnded   PlayerState = "ended"
+	PlayerError   PlayerState = "error"
+)
+
+// Active reports whether a track is loaded, i.e. it is loading, playing or paused
+func (s PlayerState) Active() bool {
+	return s == PlayerLoading || s == PlayerPlaying || s == PlayerPaused
+}
+
+// playerTransitions lists the states that can be reached from each state
+var playerTransitions = map[PlayerState][]PlayerState{
+	PlayerIdle:    {PlayerLoading},
+	PlayerLoading: {PlayerPlaying, PlayerPaused, PlayerEnded, PlayerError, PlayerIdle},
+	PlayerPlaying: {PlayerPaused, PlayerEnded, PlayerError, PlayerIdle, PlayerLoading},
+	PlayerPaused:  {PlayerPlaying, PlayerEnded, PlayerError, PlayerIdle, PlayerLoading},
+	PlayerEnded:   {PlayerLoading, PlayerIdle},
+	PlayerError:   {PlayerLoading, PlayerIdle},
+}
+
+// CanTransition reports whether a player may move from one state to another
+func CanTransition(from, to PlayerState) bool {
+	for _, state := range playerTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PlayerEvent is published by a player whenever its state changes
+type PlayerEvent struct {
+	From  PlayerState
+	State PlayerState
+	Err   error
+}
+
+// playerStateMachine holds the state of a player and publishes every
+// transition, in order, on its events channel. Publishing never blocks the
+// caller: events queue up until the subscriber reads them.
+type playerStateMachine struct {
+	mu     sync.Mutex
+	state  PlayerState
+	queue  []PlayerEvent
+	notify chan struct{}
+	events chan PlayerEvent
+}
+
+func newPlayerStateMachine() *playerStateMachine {
+	m := &playerStateMachine{
+		state:  PlayerIdle,
+		notify: make(chan struct{}, 1),
+		events: make(chan PlayerEvent),
+	}
+	go m.dispatch()
+	return m
+}
+
+// transition moves to the given state. Transitions that aren't allowed from
+// the current state are rejected, which keeps late events from a process that
+// has already been replaced from clobbering the state.
+func (m *playerStateMachine) transition(to PlayerState, err error) error {
+	return m.move("", to, err)
+}
+
+// transitionFrom moves to the given state only if the player is currently in
+// the expected state
+func (m *playerStateMachine) transitionFrom(from, to PlayerState, err error) bool {
+	return m.move(from, to, err) == nil
+}
+
+func (m *playerStateMachine) move(expected, to PlayerState, err error) error {
+	m.mu.Lock()
+	from := m.state
+	if expected != "" && from != expected {
+		m.mu.Unlock()
+		return fmt.Errorf("player is %s, not %s", from, expected)
+	}
+	if from == to {
+		m.mu.Unlock()
+		return nil
+	}
+	if !CanTransition(from, to) {
+		m.mu.Unlock()
+		return fmt.Errorf("invalid player transition from %s to %s", from, to)
+	}
+	m.state = to
+	m.queue = append(m.queue, PlayerEvent{From: from, State: to, Err: err})
+	m.mu.Unlock()
+
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (m *playerStateMachine) State() PlayerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+func (m *playerStateMachine) Events() <-chan PlayerEvent {
+	return m.events
+}
+
+// dispatch forwards queued events to the events channel
+func (m *playerStateMachine) dispatch() {
+	for range m.notify {
+		for {
+			m.mu.Lock()
+			if len(m.queue) == 0 {
+				m.mu.Unlock()
+				break
+			}
+			event := m.queue[0]
+			m.queue = m.queue[1:]
+			m.mu.Unlock()
+
+			m.events <- event
+		}
+	}
+}
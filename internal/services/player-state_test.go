@@ -0,0 +1,304 @@
+package services
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess isn't a real test, it stands in for a player process.
+// FAKE_PLAYER says what it does: "exit" exits at once, "fail" exits with an
+// error and "wait" runs until it is killed.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("FAKE_PLAYER") {
+	case "exit":
+		os.Exit(0)
+	case "fail":
+		os.Exit(3)
+	case "wait":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+func fakePlayerCommand(behaviour string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "FAKE_PLAYER="+behaviour)
+	return cmd
+}
+
+// nextEvent waits for the next event published by m
+func nextEvent(t *testing.T, m *playerStateMachine) PlayerEvent {
+	t.Helper()
+	select {
+	case event := <-m.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a player event")
+		return PlayerEvent{}
+	}
+}
+
+// expectEvents checks that m publishes events moving through states, in order
+func expectEvents(t *testing.T, m *playerStateMachine, states ...PlayerState) {
+	t.Helper()
+	for _, state := range states {
+		if event := nextEvent(t, m); event.State != state {
+			t.Fatalf("got event %s -> %s, want %s", event.From, event.State, state)
+		}
+	}
+}
+
+// expectNoEvent checks that m doesn't publish anything for a while
+func expectNoEvent(t *testing.T, m *playerStateMachine) {
+	t.Helper()
+	select {
+	case event := <-m.Events():
+		t.Fatalf("got unexpected event %s -> %s", event.From, event.State)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to PlayerState
+		want     bool
+	}{
+		{PlayerIdle, PlayerLoading, true},
+		{PlayerIdle, PlayerPlaying, false},
+		{PlayerIdle, PlayerEnded, false},
+		{PlayerLoading, PlayerPlaying, true},
+		{PlayerPlaying, PlayerPaused, true},
+		{PlayerPaused, PlayerPlaying, true},
+		{PlayerPlaying, PlayerEnded, true},
+		{PlayerPlaying, PlayerIdle, true},
+		{PlayerEnded, PlayerPlaying, false},
+		{PlayerEnded, PlayerLoading, true},
+		{PlayerError, PlayerPaused, false},
+		{PlayerError, PlayerIdle, true},
+	}
+	for _, test := range tests {
+		if got := CanTransition(test.from, test.to); got != test.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestStateMachineTransitions(t *testing.T) {
+	m := newPlayerStateMachine()
+
+	if err := m.transition(PlayerPlaying, nil); err == nil {
+		t.Error("idle -> playing was allowed")
+	}
+	if m.State() != PlayerIdle {
+		t.Errorf("state after a rejected transition = %s, want idle", m.State())
+	}
+
+	for _, state := range []PlayerState{PlayerLoading, PlayerPlaying, PlayerPaused, PlayerPlaying} {
+		if err := m.transition(state, nil); err != nil {
+			t.Fatalf("transition to %s: %v", state, err)
+		}
+	}
+	// Moving to the current state is a no-op and publishes nothing
+	if err := m.transition(PlayerPlaying, nil); err != nil {
+		t.Errorf("playing -> playing: %v", err)
+	}
+	if m.transitionFrom(PlayerLoading, PlayerPlaying, nil) {
+		t.Error("transitionFrom(loading) succeeded while playing")
+	}
+	if !m.transitionFrom(PlayerPlaying, PlayerEnded, nil) {
+		t.Error("transitionFrom(playing) failed while playing")
+	}
+
+	// Events queue up until they are read, in order
+	expectEvents(t, m, PlayerLoading, PlayerPlaying, PlayerPaused, PlayerPlaying, PlayerEnded)
+	expectNoEvent(t, m)
+}
+
+func TestStateMachineEventFrom(t *testing.T) {
+	m := newPlayerStateMachine()
+	m.transition(PlayerLoading, nil)
+	m.transition(PlayerError, os.ErrNotExist)
+
+	if event := nextEvent(t, m); event.From != PlayerIdle || event.State != PlayerLoading {
+		t.Errorf("first event = %s -> %s, want idle -> loading", event.From, event.State)
+	}
+	event := nextEvent(t, m)
+	if event.From != PlayerLoading || event.State != PlayerError || event.Err != os.ErrNotExist {
+		t.Errorf("second event = %s -> %s (%v), want loading -> error (%v)", event.From, event.State, event.Err, os.ErrNotExist)
+	}
+}
+
+func TestStateMachineConcurrentDelivery(t *testing.T) {
+	m := newPlayerStateMachine()
+	m.transition(PlayerLoading, nil)
+	m.transition(PlayerPlaying, nil)
+	expectEvents(t, m, PlayerLoading, PlayerPlaying)
+
+	// Pause and resume from several goroutines while the events are read
+	const workers, rounds = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				m.transition(PlayerPaused, nil)
+				m.transition(PlayerPlaying, nil)
+				m.State()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		m.transition(PlayerIdle, nil)
+		close(done)
+	}()
+
+	// Every event continues from the state the previous one moved to
+	state := PlayerPlaying
+	for state != PlayerIdle {
+		event := nextEvent(t, m)
+		if event.From != state {
+			t.Fatalf("event %s -> %s doesn't follow %s", event.From, event.State, state)
+		}
+		state = event.State
+	}
+	<-done
+	expectNoEvent(t, m)
+}
+
+func TestProcessPlayerEnded(t *testing.T) {
+	p := newProcessPlayer()
+	if err := p.start(fakePlayerCommand("exit"), "track"); err != nil {
+		t.Fatal(err)
+	}
+	p.transition(PlayerPlaying, nil)
+
+	// The process exiting on its own means the track has ended
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying, PlayerEnded)
+	if p.running() {
+		t.Error("player still running after its process exited")
+	}
+}
+
+func TestProcessPlayerError(t *testing.T) {
+	p := newProcessPlayer()
+	if err := p.start(fakePlayerCommand("fail"), "track"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectEvents(t, p.playerStateMachine, PlayerLoading)
+	if event := nextEvent(t, p.playerStateMachine); event.State != PlayerError || event.Err == nil {
+		t.Errorf("got %s (%v), want error with a cause", event.State, event.Err)
+	}
+}
+
+func TestProcessPlayerKilled(t *testing.T) {
+	p := newProcessPlayer()
+	if err := p.start(fakePlayerCommand("wait"), "track"); err != nil {
+		t.Fatal(err)
+	}
+	p.transition(PlayerPlaying, nil)
+	p.kill()
+
+	// Stopping is reported as idle, never as the track having ended
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying, PlayerIdle)
+	expectNoEvent(t, p.playerStateMachine)
+}
+
+func TestProcessPlayerReplaced(t *testing.T) {
+	p := newProcessPlayer()
+	first := fakePlayerCommand("wait")
+	if err := p.start(first, "first"); err != nil {
+		t.Fatal(err)
+	}
+	p.transition(PlayerPlaying, nil)
+
+	// A new track starts while the old process is still being killed
+	second := fakePlayerCommand("wait")
+	p.kill()
+	if err := p.start(second, "second"); err != nil {
+		t.Fatal(err)
+	}
+	p.transition(PlayerPlaying, nil)
+
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying, PlayerIdle, PlayerLoading, PlayerPlaying)
+	expectNoEvent(t, p.playerStateMachine)
+	p.kill()
+	expectEvents(t, p.playerStateMachine, PlayerIdle)
+}
+
+func TestProcessPlayerDetached(t *testing.T) {
+	p := newProcessPlayer()
+	p.detached = true
+	if err := p.start(fakePlayerCommand("exit"), "track"); err != nil {
+		t.Fatal(err)
+	}
+	p.transition(PlayerPlaying, nil)
+
+	// The launcher exiting says nothing about the track
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying)
+	expectNoEvent(t, p.playerStateMachine)
+	if p.State() != PlayerPlaying {
+		t.Errorf("state = %s, want playing", p.State())
+	}
+	if !p.running() {
+		t.Error("detached player stopped running when its launcher exited")
+	}
+
+	p.kill()
+	expectEvents(t, p.playerStateMachine, PlayerIdle)
+}
+
+func TestProcessPlayerDetachedLaunchFails(t *testing.T) {
+	p := newProcessPlayer()
+	p.detached = true
+	if err := p.start(fakePlayerCommand("fail"), "track"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerError)
+}
+
+func TestSystemPlayerArgs(t *testing.T) {
+	url := "https://example.com/audio"
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"linux", "-really-quiet -novo " + url},
+		{"windows", "/play " + url},
+	}
+	for _, test := range tests {
+		if got := strings.Join(systemPlayerArgs(test.goos, url), " "); got != test.want {
+			t.Errorf("systemPlayerArgs(%s) = %q, want %q", test.goos, got, test.want)
+		}
+	}
+}
+
+func TestSystemPlayerLifecycle(t *testing.T) {
+	p := NewSystemPlayer("mplayer")
+	p.detached = false
+	if err := p.play(fakePlayerCommand("wait"), "first"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying)
+
+	// Playing another track stops the first without it counting as ended
+	if err := p.play(fakePlayerCommand("exit"), "second"); err != nil {
+		t.Fatal(err)
+	}
+	expectEvents(t, p.playerStateMachine, PlayerIdle, PlayerLoading, PlayerPlaying, PlayerEnded)
+	expectNoEvent(t, p.playerStateMachine)
+
+	if err := p.play(fakePlayerCommand("wait"), "third"); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+	expectEvents(t, p.playerStateMachine, PlayerLoading, PlayerPlaying, PlayerIdle)
+	expectNoEvent(t, p.playerStateMachine)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+func lookupQuickTime() (string, bool) {
+	if runtime.GOOS != "darwin" {
+		return "", false
+	}
+	return lookupFirst("open")
+}
+
+// QuickTimePlayer plays media with QuickTime Player through `open`. As
+// `open` returns once QuickTime has the media, it can't tell when the track
+// ends.
+type QuickTimePlayer struct {
+	processPlayer
+	path string
+}
+
+func NewQuickTimePlayer(path string) *QuickTimePlayer {
+	player := &QuickTimePlayer{
+		processPlayer: newProcessPlayer(),
+		path:          path,
+	}
+	player.detached = true
+	return player
+}
+
+func (p *QuickTimePlayer) Name() string {
+	return "quicktime"
+}
+
+func (p *QuickTimePlayer) Play(url string) error {
+	p.Stop()
+	if err := p.start(exec.Command(p.path, "-g", "-a", "QuickTime Player", url), url); err != nil {
+		return err
+	}
+	p.transition(PlayerPlaying, nil)
+	return nil
+}
+
+func (p *QuickTimePlayer) Pause() error {
+	if !p.running() {
+		return fmt.Errorf("no media is playing")
+	}
+	if err := exec.Command("killall", "-STOP", "QuickTime Player").Run(); err != nil {
+		return err
+	}
+	p.transition(PlayerPaused, nil)
+	return nil
+}
+
+func (p *QuickTimePlayer) Resume() error {
+	if !p.running() || p.State() != PlayerPaused {
+		return fmt.Errorf("no paused media to resume")
+	}
+	if err := exec.Command("killall", "-CONT", "QuickTime Player").Run(); err != nil {
+		return err
+	}
+	p.transition(PlayerPlaying, nil)
+	return nil
+}
+
+func (p *QuickTimePlayer) Stop() error {
+	p.kill()
+	return nil
+}
+
+func lookupSystemPlayer() (string, bool) {
+	switch runtime.GOOS {
+	case "windows":
+		return lookupFirst(
+			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Windows Media Player", "wmplayer.exe"),
+			filepath.Join(os.Getenv("ProgramFiles"), "Windows Media Player", "wmplayer.exe"),
+		)
+	case "linux":
+		return lookupFirst("mplayer")
+	}
+	return "", false
+}
+
+// SystemPlayer launches whatever media player is installed and can only start
+// and stop playback. Windows Media Player hands the media to a running
+// instance and exits, so on Windows it can't tell when the track ends.
+type SystemPlayer struct {
+	processPlayer
+	path string
+}
+
+func NewSystemPlayer(path string) *SystemPlayer {
+	player := &SystemPlayer{
+		processPlayer: newProcessPlayer(),
+		path:          path,
+	}
+	player.detached = runtime.GOOS == "windows"
+	return player
+}
+
+func (p *SystemPlayer) Name() string {
+	return "system"
+}
+
+func (p *SystemPlayer) Play(url string) error {
+	return p.play(exec.Command(p.path, systemPlayerArgs(runtime.GOOS, url)...), url)
+}
+
+// play stops the current track and plays url with cmd
+func (p *SystemPlayer) play(cmd *exec.Cmd, url string) error {
+	p.Stop()
+	if err := p.start(cmd, url); err != nil {
+		return err
+	}
+	p.transition(PlayerPlaying, nil)
+	return nil
+}
+
+// systemPlayerArgs returns the arguments that make the system player of goos
+// play url
+func systemPlayerArgs(goos, url string) []string {
+	if goos == "windows" {
+		return []string{"/play", url}
+	}
+	// mplayer, without a video window
+	return []string{"-really-quiet", "-novo", url}
+}
+
+func (p *SystemPlayer) Pause() error {
+	return fmt.Errorf("pause not supported on this OS")
+}
+
+func (p *SystemPlayer) Resume() error {
+	return fmt.Errorf("resume not supported on this OS")
+}
+
+func (p *SystemPlayer) Stop() error {
+	p.kill()
+	return nil
+}
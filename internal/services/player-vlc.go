@@ -0,0 +1,233 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	vlcPort     = "8080"   // VLC HTTP interface port
+	vlcPassword = "ytview" // VLC HTTP interface password
+)
+
+func lookupVlc() (string, bool) {
+	return lookupFirst(
+		"vlc",
+		"/Applications/VLC.app/Contents/MacOS/VLC",
+		filepath.Join(os.Getenv("HOME"), "Applications/VLC.app/Contents/MacOS/VLC"),
+		filepath.Join(os.Getenv("ProgramFiles(x86)"), "VideoLAN", "VLC", "vlc.exe"),
+		filepath.Join(os.Getenv("ProgramFiles"), "VideoLAN", "VLC", "vlc.exe"),
+	)
+}
+
+// vlcStatus is the subset of VLC's requests/status.xml that ytview uses
+type vlcStatus struct {
+	State  string `xml:"state"`
+	Time   int    `xml:"time"`
+	Length int    `xml:"length"`
+	Volume int    `xml:"volume"`
+}
+
+// VlcPlayer plays media with VLC and controls it through its HTTP interface
+type VlcPlayer struct {
+	processPlayer
+	path    string
+	baseURL string
+	client  *http.Client
+}
+
+func NewVlcPlayer(path string) *VlcPlayer {
+	return &VlcPlayer{
+		processPlayer: newProcessPlayer(),
+		path:          path,
+		baseURL:       "http://127.0.0.1:" + vlcPort,
+		client:        &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+func (p *VlcPlayer) Name() string {
+	return "vlc"
+}
+
+func (p *VlcPlayer) Play(url string) error {
+	p.Stop()
+
+	// Start VLC with HTTP interface
+	cmd := exec.Command(p.path,
+		"--intf", "http", // Enable HTTP interface
+		"--http-host", "127.0.0.1", // Only listen locally
+		"--http-port", vlcPort, // Set HTTP port
+		"--http-password", vlcPassword, // Set password for HTTP interface
+		"--extraintf", "http", // Add HTTP as extra interface
+		"--no-video",      // Disable video output
+		"--play-and-exit", // Exit when playback ends
+		url)
+	if err := p.start(cmd, url); err != nil {
+		return err
+	}
+
+	// Give VLC a moment to start up its HTTP interface
+	time.Sleep(100 * time.Millisecond)
+	go p.waitForPlayback()
+	return nil
+}
+
+// waitForPlayback polls VLC until its HTTP interface answers and playback has
+// started. VLC has no portable option for the initial volume, so it is set
+// here too.
+func (p *VlcPlayer) waitForPlayback() {
+	volume := p.startVolume()
+	for i := 0; i < 50 && p.running(); i++ {
+		if volume >= 0 && p.sendVolume(volume) == nil {
+			volume = -1
+		}
+		if status, err := p.status(); err == nil && status.State == "playing" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Don't leave the player loading forever if VLC never reports playing
+	if p.running() {
+		p.transitionFrom(PlayerLoading, PlayerPlaying, nil)
+	}
+}
+
+func (p *VlcPlayer) Pause() error {
+	if !p.running() {
+		return fmt.Errorf("no media is playing")
+	}
+	if _, err := p.command("pl_pause", ""); err != nil {
+		return err
+	}
+	p.transition(PlayerPaused, nil)
+	return nil
+}
+
+func (p *VlcPlayer) Resume() error {
+	if !p.running() || p.State() != PlayerPaused {
+		return fmt.Errorf("no paused media to resume")
+	}
+	if _, err := p.command("pl_play", ""); err != nil {
+		return err
+	}
+	p.transition(PlayerPlaying, nil)
+	return nil
+}
+
+func (p *VlcPlayer) Stop() error {
+	// Stopping through the HTTP interface would make VLC exit on its own
+	// (--play-and-exit), which looks like the track ended
+	p.kill()
+	return nil
+}
+
+func (p *VlcPlayer) Seek(pos time.Duration) error {
+	_, err := p.command("seek", strconv.Itoa(int(pos.Seconds())))
+	return err
+}
+
+func (p *VlcPlayer) Position() (time.Duration, error) {
+	status, err := p.status()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(status.Time) * time.Second, nil
+}
+
+func (p *VlcPlayer) Duration() (time.Duration, error) {
+	status, err := p.status()
+	if err != nil {
+		return 0, err
+	}
+	if status.Length <= 0 {
+		return 0, fmt.Errorf("vlc: length not known yet")
+	}
+	return time.Duration(status.Length) * time.Second, nil
+}
+
+// Volume returns the volume as a percentage. VLC reports 256 for 100%.
+func (p *VlcPlayer) Volume() (int, error) {
+	if !p.running() {
+		if volume := p.startVolume(); volume >= 0 {
+			return volume, nil
+		}
+	}
+	status, err := p.status()
+	if err != nil {
+		return 0, err
+	}
+	return (status.Volume*100 + 128) / 256, nil
+}
+
+func (p *VlcPlayer) SetVolume(volume int) error {
+	volume = p.rememberVolume(volume)
+	if !p.running() {
+		return nil
+	}
+	return p.sendVolume(volume)
+}
+
+func (p *VlcPlayer) sendVolume(volume int) error {
+	_, err := p.command("volume", strconv.Itoa(volume*256/100))
+	return err
+}
+
+// status fetches status.xml and syncs the player state with VLC's
+func (p *VlcPlayer) status() (*vlcStatus, error) {
+	status, err := p.command("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	if p.running() {
+		switch status.State {
+		case "playing":
+			p.transition(PlayerPlaying, nil)
+		case "paused":
+			p.transition(PlayerPaused, nil)
+		}
+	}
+	return status, nil
+}
+
+// command sends a command to VLC's HTTP interface and returns the resulting
+// status. An empty command only fetches the status.
+func (p *VlcPlayer) command(command string, val string) (*vlcStatus, error) {
+	query := url.Values{}
+	if command != "" {
+		query.Set("command", command)
+	}
+	if val != "" {
+		query.Set("val", val)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/requests/status.xml?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth("", vlcPassword)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vlc: unexpected status %s", resp.Status)
+	}
+
+	var status vlcStatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestVlc returns a VlcPlayer whose HTTP interface is a stub serving
+// status.xml with the given values
+func newTestVlc(t *testing.T, state string, position, length, volume int) (*VlcPlayer, *[]string) {
+	t.Helper()
+	var commands []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != vlcPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/requests/status.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if command := r.URL.Query().Get("command"); command != "" {
+			commands = append(commands, command+" "+r.URL.Query().Get("val"))
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8" standalone="yes" ?>
+<root>
+  <fullscreen>false</fullscreen>
+  <volume>%d</volume>
+  <length>%d</length>
+  <time>%d</time>
+  <state>%s</state>
+  <position>0.25</position>
+</root>`, volume, length, position, state)
+	}))
+	t.Cleanup(server.Close)
+
+	player := NewVlcPlayer("vlc")
+	player.baseURL = server.URL
+	return player, &commands
+}
+
+func TestVlcStatus(t *testing.T) {
+	player, _ := newTestVlc(t, "playing", 75, 300, 128)
+
+	position, err := player.Position()
+	if err != nil || position != 75*time.Second {
+		t.Errorf("Position() = %v, %v; want 1m15s", position, err)
+	}
+	duration, err := player.Duration()
+	if err != nil || duration != 5*time.Minute {
+		t.Errorf("Duration() = %v, %v; want 5m0s", duration, err)
+	}
+	volume, err := player.Volume()
+	if err != nil || volume != 50 {
+		t.Errorf("Volume() = %d, %v; want 50", volume, err)
+	}
+}
+
+func TestVlcVolumeScale(t *testing.T) {
+	tests := []struct {
+		vlc  int
+		want int
+	}{
+		{0, 0},
+		{256, 100},
+		{320, 125},
+		{3, 1},
+		{1, 0},
+	}
+	for _, test := range tests {
+		player, _ := newTestVlc(t, "playing", 0, 0, test.vlc)
+		if volume, err := player.Volume(); err != nil || volume != test.want {
+			t.Errorf("Volume() with VLC volume %d = %d, %v; want %d", test.vlc, volume, err, test.want)
+		}
+	}
+}
+
+func TestVlcLengthNotKnown(t *testing.T) {
+	// VLC reports a length of 0 until it has read enough of the stream
+	player, _ := newTestVlc(t, "playing", 3, 0, 256)
+
+	if _, err := player.Duration(); err == nil {
+		t.Error("Duration() with unknown length succeeded, want an error")
+	}
+	if position, err := player.Position(); err != nil || position != 3*time.Second {
+		t.Errorf("Position() = %v, %v; want 3s", position, err)
+	}
+	if _, err := SeekBy(player, 10*time.Second); err != nil {
+		t.Errorf("SeekBy() with unknown length: %v", err)
+	}
+}
+
+func TestVlcCommands(t *testing.T) {
+	player, commands := newTestVlc(t, "playing", 0, 0, 256)
+
+	if err := player.Seek(90 * time.Second); err != nil {
+		t.Fatalf("Seek(): %v", err)
+	}
+	if err := player.sendVolume(50); err != nil {
+		t.Fatalf("sendVolume(): %v", err)
+	}
+	want := []string{"seek 90", "volume 128"}
+	if fmt.Sprint(*commands) != fmt.Sprint(want) {
+		t.Errorf("commands sent = %q, want %q", *commands, want)
+	}
+}
+
+func TestVlcUnauthorized(t *testing.T) {
+	player, _ := newTestVlc(t, "playing", 0, 0, 256)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	player.baseURL = server.URL
+
+	if _, err := player.Position(); err == nil {
+		t.Error("Position() with a rejected password succeeded, want an error")
+	}
+}
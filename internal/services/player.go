@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPlayerUnsupported is returned by backends that can't perform an operation
+var ErrPlayerUnsupported = errors.New("operation not supported by player")
+
+// Player controls a single media playback process
+type Player interface {
+	Name() string
+	Play(url string) error
+	Pause() error
+	Resume() error
+	Stop() error
+	Seek(pos time.Duration) error
+	Position() (time.Duration, error)
+	Duration() (time.Duration, error)
+	Volume() (int, error)
+	SetVolume(volume int) error
+	State() PlayerState
+	Events() <-chan PlayerEvent
+}
+
+// playerBackend describes a player implementation that can be selected at runtime
+type playerBackend struct {
+	name string
+	// lookup returns the executable for the backend, or false if it isn't installed
+	lookup func() (string, bool)
+	create func(path string) Player
+}
+
+// playerBackends lists the available backends in order of preference
+var playerBackends = []playerBackend{
+	{name: "vlc", lookup: lookupVlc, create: func(path string) Player { return NewVlcPlayer(path) }},
+	{name: "mpv", lookup: lookupMpv, create: func(path string) Player { return NewMpvPlayer(path) }},
+	{name: "quicktime", lookup: lookupQuickTime, create: func(path string) Player { return NewQuickTimePlayer(path) }},
+	{name: "system", lookup: lookupSystemPlayer, create: func(path string) Player { return NewSystemPlayer(path) }},
+}
+
+// NewPlayer returns the first available backend. YTVIEW_PLAYER can be set to
+// the name of a backend to prefer it over the default order.
+func NewPlayer() (Player, error) {
+	if name := os.Getenv("YTVIEW_PLAYER"); name != "" {
+		player, err := NewPlayerByName(name)
+		if err == nil {
+			return player, nil
+		}
+		log.Printf("Warning: %v, falling back to default player", err)
+	}
+
+	for _, backend := range playerBackends {
+		if path, ok := backend.lookup(); ok {
+			return backend.create(path), nil
+		}
+	}
+	return nil, fmt.Errorf("no suitable media player found")
+}
+
+// NewPlayerByName returns the backend registered under the given name
+func NewPlayerByName(name string) (Player, error) {
+	for _, backend := range playerBackends {
+		if !strings.EqualFold(backend.name, name) {
+			continue
+		}
+		path, ok := backend.lookup()
+		if !ok {
+			return nil, fmt.Errorf("player %q is not installed", name)
+		}
+		return backend.create(path), nil
+	}
+	return nil, fmt.Errorf("unknown player %q", name)
+}
+
+// lookupFirst returns the first of the given paths or executable names that exists
+func lookupFirst(candidates ...string) (string, bool) {
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if strings.ContainsRune(candidate, os.PathSeparator) {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, true
+			}
+			continue
+		}
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// processPlayer holds the process and state shared by all backends that
+// play media by launching an external program
+type processPlayer struct {
+	*playerStateMachine
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	url    string
+	volume int // volume to start playback at, -1 for the player's default
+
+	// detached is set for backends whose program only hands the media to
+	// another application and exits. Its exit says nothing about the track,
+	// so they never report that it ended.
+	detached bool
+}
+
+func newProcessPlayer() processPlayer {
+	return processPlayer{
+		playerStateMachine: newPlayerStateMachine(),
+		volume:             -1,
+	}
+}
+
+// start launches cmd and watches it until it exits. The player stays in the
+// loading state until the backend knows that playback has started. A
+// detached player stays in whatever state it is in once cmd exits cleanly.
+func (p *processPlayer) start(cmd *exec.Cmd, url string) error {
+	p.transition(PlayerLoading, nil)
+	if err := cmd.Start(); err != nil {
+		p.transition(PlayerError, err)
+		return err
+	}
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.url = url
+	p.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		if err == nil && p.detached {
+			return
+		}
+
+		p.mu.Lock()
+		current := p.cmd == cmd
+		if current {
+			p.cmd = nil
+			p.url = ""
+		}
+		p.mu.Unlock()
+
+		// A process that was replaced or stopped has already left the state machine
+		if !current {
+			return
+		}
+		if err != nil {
+			p.transition(PlayerError, fmt.Errorf("player exited: %w", err))
+		} else {
+			p.transition(PlayerEnded, nil)
+		}
+	}()
+	return nil
+}
+
+// kill terminates the running process, if any
+func (p *processPlayer) kill() {
+	p.mu.Lock()
+	cmd := p.cmd
+	p.cmd = nil
+	p.url = ""
+	p.mu.Unlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		exec.Command("taskkill", "/F", "/T", "/PID", fmt.Sprint(cmd.Process.Pid)).Run()
+	} else {
+		cmd.Process.Kill()
+	}
+	p.transition(PlayerIdle, nil)
+}
+
+func (p *processPlayer) running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cmd != nil
+}
+
+// runs reports whether cmd is the process that is playing
+func (p *processPlayer) runs(cmd *exec.Cmd) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cmd == cmd
+}
+
+// rememberVolume stores the volume so that the next process starts with it
+func (p *processPlayer) rememberVolume(volume int) int {
+	volume = max(0, min(volume, 100))
+	p.mu.Lock()
+	p.volume = volume
+	p.mu.Unlock()
+	return volume
+}
+
+func (p *processPlayer) startVolume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume
+}
+
+func (p *processPlayer) Seek(pos time.Duration) error {
+	return ErrPlayerUnsupported
+}
+
+func (p *processPlayer) Position() (time.Duration, error) {
+	return 0, ErrPlayerUnsupported
+}
+
+func (p *processPlayer) Duration() (time.Duration, error) {
+	return 0, ErrPlayerUnsupported
+}
+
+func (p *processPlayer) Volume() (int, error) {
+	return 0, ErrPlayerUnsupported
+}
+
+func (p *processPlayer) SetVolume(volume int) error {
+	return ErrPlayerUnsupported
+}
+
+// SeekBy moves the playback position of p by offset, clamped to the track
+func SeekBy(p Player, offset time.Duration) (time.Duration, error) {
+	position, err := p.Position()
+	if err != nil {
+		return 0, err
+	}
+
+	position += offset
+	if duration, err := p.Duration(); err == nil && duration > 0 && position > duration {
+		position = duration
+	}
+	if position < 0 {
+		position = 0
+	}
+	return position, p.Seek(position)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// PlaylistFormat is a file format playlists can be exported to and imported from
+type PlaylistFormat string
+
+const (
+	FormatM3U  PlaylistFormat = "m3u"
+	FormatXSPF PlaylistFormat = "xspf"
+	FormatJSON PlaylistFormat = "json"
+)
+
+// PlaylistFormatFromPath picks the format from the extension of path
+func PlaylistFormatFromPath(path string) (PlaylistFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		return FormatM3U, nil
+	case ".xspf":
+		return FormatXSPF, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unsupported playlist format %q, use .m3u, .m3u8, .xspf or .json", filepath.Ext(path))
+}
+
+// VideoURL returns the watch URL of a YouTube video
+func VideoURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + id
+}
+
+// ParseVideoID returns the video id from a YouTube watch, short or music URL
+func ParseVideoID(input string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(input))
+	if err != nil {
+		return "", false
+	}
+
+	switch strings.TrimPrefix(u.Hostname(), "www.") {
+	case "youtube.com", "m.youtube.com", "music.youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return id, true
+		}
+		if strings.HasPrefix(u.Path, "/shorts/") {
+			return strings.TrimPrefix(u.Path, "/shorts/"), true
+		}
+	case "youtu.be":
+		if id := strings.Trim(u.Path, "/"); id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// ExportPlaylist writes playlist to path in the format given by its extension
+func ExportPlaylist(path string, playlist models.Playlist) error {
+	format, err := PlaylistFormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	switch format {
+	case FormatM3U:
+		err = writeM3U(file, playlist)
+	case FormatXSPF:
+		err = writeXSPF(file, playlist)
+	case FormatJSON:
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(playlist)
+	}
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// ImportPlaylist reads a playlist file. Entries that are only a YouTube URL
+// are looked up with yt-dlp to fill in their title, channel and duration.
+func ImportPlaylist(path string) (models.Playlist, error) {
+	format, err := PlaylistFormatFromPath(path)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	defer file.Close()
+
+	var playlist models.Playlist
+	switch format {
+	case FormatM3U:
+		playlist, err = readM3U(file)
+	case FormatXSPF:
+		playlist, err = readXSPF(file)
+	case FormatJSON:
+		err = json.NewDecoder(file).Decode(&playlist)
+	}
+	if err != nil {
+		return models.Playlist{}, err
+	}
+
+	if playlist.Name == "" {
+		playlist.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if playlist.CreatedAt.IsZero() {
+		playlist.CreatedAt = time.Now()
+	}
+	for i := range playlist.Entries {
+		if playlist.Entries[i].AddedAt.IsZero() {
+			playlist.Entries[i].AddedAt = playlist.CreatedAt
+		}
+	}
+	return playlist, nil
+}
+
+// resolveVideo looks up a video that only has an id with yt-dlp
+func resolveVideo(id string) (models.Video, error) {
+	stdout, err := GetYtDlpInfo(VideoURL(id))
+	if err != nil {
+		return models.Video{}, err
+	}
+
+	var item models.YtDlpVideoResponse
+	if err := json.Unmarshal(stdout, &item); err != nil {
+		return models.Video{}, err
+	}
+	return videoFromYtDlp(item), nil
+}
+
+// addImportedEntry appends a video read from a playlist file, resolving it if
+// the file didn't say what it is
+func addImportedEntry(playlist *models.Playlist, location string, video models.Video) {
+	id, ok := ParseVideoID(location)
+	if !ok {
+		log.Printf("Warning: skipping %q, it is not a YouTube video", location)
+		return
+	}
+	video.ID = id
+
+	if video.Title == "" {
+		resolved, err := resolveVideo(id)
+		if err != nil {
+			log.Printf("Error resolving %q: %v", location, err)
+			video.Title = location
+		} else {
+			video = resolved
+		}
+	}
+	playlist.Entries = append(playlist.Entries, models.PlaylistEntry{Video: video})
+}
+
+func writeM3U(w io.Writer, playlist models.Playlist) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "#EXTM3U")
+	if playlist.Name != "" {
+		fmt.Fprintf(out, "#PLAYLIST:%s\n", playlist.Name)
+	}
+	for _, entry := range playlist.Entries {
+		video := entry.Video
+		seconds := int(ParseDuration(video.Duration).Seconds())
+		if seconds == 0 {
+			seconds = -1 // Unknown length
+		}
+		fmt.Fprintf(out, "#EXTINF:%d,%s - %s\n", seconds, video.Channel, video.Title)
+		fmt.Fprintln(out, VideoURL(video.ID))
+	}
+	return out.Flush()
+}
+
+func readM3U(r io.Reader) (models.Playlist, error) {
+	var playlist models.Playlist
+	var pending models.Video
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == "#EXTM3U":
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			playlist.Name = strings.TrimPrefix(line, "#PLAYLIST:")
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<seconds>,<channel> - <title>
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			seconds, name, _ := strings.Cut(info, ",")
+			pending = models.Video{Title: name}
+			if channel, title, ok := strings.Cut(name, " - "); ok {
+				pending.Channel = channel
+				pending.Title = title
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(seconds)); err == nil && n > 0 {
+				pending.Duration = FormatDuration(time.Duration(n) * time.Second)
+			}
+		case strings.HasPrefix(line, "#"):
+			// Other extended M3U directives aren't used
+		default:
+			addImportedEntry(&playlist, line, pending)
+			pending = models.Video{}
+		}
+	}
+	return playlist, scanner.Err()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Duration int64  `xml:"duration,omitempty"` // milliseconds
+	Image    string `xml:"image,omitempty"`
+}
+
+func writeXSPF(w io.Writer, playlist models.Playlist) error {
+	doc := xspfPlaylist{Version: "1", Title: playlist.Name}
+	for _, entry := range playlist.Entries {
+		video := entry.Video
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: VideoURL(video.ID),
+			Title:    video.Title,
+			Creator:  video.Channel,
+			Duration: ParseDuration(video.Duration).Milliseconds(),
+			Image:    video.Thumbnail,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func readXSPF(r io.Reader) (models.Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return models.Playlist{}, err
+	}
+
+	playlist := models.Playlist{Name: doc.Title}
+	for _, track := range doc.Tracks {
+		video := models.Video{
+			Title:     track.Title,
+			Channel:   track.Creator,
+			Thumbnail: track.Image,
+		}
+		if track.Duration > 0 {
+			video.Duration = FormatDuration(time.Duration(track.Duration) * time.Millisecond)
+		}
+		addImportedEntry(&playlist, strings.TrimSpace(track.Location), video)
+	}
+	return playlist, nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+func testPlaylist() models.Playlist {
+	added := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return models.Playlist{
+		Name:      "Road trip",
+		CreatedAt: added,
+		Entries: []models.PlaylistEntry{
+			{Video: models.Video{ID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Channel: "Rick Astley", Duration: "3:33"}, AddedAt: added},
+			{Video: models.Video{ID: "abc-_123XYZ", Title: "Live - Part 2", Channel: "Sigur Rós", Duration: "1:02:03"}, AddedAt: added},
+			{Video: models.Video{ID: "zzz", Title: "No length", Channel: "Somebody"}, AddedAt: added},
+		},
+	}
+}
+
+func TestPlaylistRoundTrip(t *testing.T) {
+	for _, name := range []string{"trip.m3u", "trip.m3u8", "trip.xspf", "trip.json"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			want := testPlaylist()
+			if err := ExportPlaylist(path, want); err != nil {
+				t.Fatalf("ExportPlaylist(): %v", err)
+			}
+			got, err := ImportPlaylist(path)
+			if err != nil {
+				t.Fatalf("ImportPlaylist(): %v", err)
+			}
+
+			if got.Name != want.Name {
+				t.Errorf("name = %q, want %q", got.Name, want.Name)
+			}
+			if len(got.Entries) != len(want.Entries) {
+				t.Fatalf("got %d entries, want %d", len(got.Entries), len(want.Entries))
+			}
+			for i, entry := range got.Entries {
+				video, wantVideo := entry.Video, want.Entries[i].Video
+				if video.ID != wantVideo.ID || video.Title != wantVideo.Title || video.Channel != wantVideo.Channel {
+					t.Errorf("entry %d = %q %q by %q, want %q %q by %q", i,
+						video.ID, video.Title, video.Channel, wantVideo.ID, wantVideo.Title, wantVideo.Channel)
+				}
+				if video.Duration != wantVideo.Duration {
+					t.Errorf("entry %d lasts %q, want %q", i, video.Duration, wantVideo.Duration)
+				}
+				if entry.AddedAt.IsZero() {
+					t.Errorf("entry %d has no time it was added", i)
+				}
+			}
+		})
+	}
+}
+
+func TestImportM3UWithoutName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Evening.m3u")
+	content := strings.Join([]string{
+		"#EXTM3U",
+		"#EXTVLCOPT:network-caching=1000",
+		"#EXTINF:200,Only a title",
+		"https://youtu.be/first",
+		"",
+		"#EXTINF:-1,Band - Song",
+		"https://music.youtube.com/watch?v=second&list=RD",
+		"#EXTINF:10,Not YouTube - Skipped",
+		"/home/me/music/local.mp3",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playlist, err := ImportPlaylist(path)
+	if err != nil {
+		t.Fatalf("ImportPlaylist(): %v", err)
+	}
+	if playlist.Name != "Evening" {
+		t.Errorf("name = %q, want the file name", playlist.Name)
+	}
+	want := []models.Video{
+		{ID: "first", Title: "Only a title", Duration: "3:20"},
+		{ID: "second", Title: "Song", Channel: "Band"},
+	}
+	if len(playlist.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(playlist.Entries), len(want))
+	}
+	for i, entry := range playlist.Entries {
+		if entry.Video != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entry.Video, want[i])
+		}
+	}
+}
+
+func TestPlaylistFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want PlaylistFormat
+	}{
+		{"a.m3u", FormatM3U},
+		{"a.M3U8", FormatM3U},
+		{"dir/a.xspf", FormatXSPF},
+		{"a.json", FormatJSON},
+		{"a.pls", ""},
+		{"a", ""},
+	}
+	for _, test := range tests {
+		got, err := PlaylistFormatFromPath(test.path)
+		if got != test.want || (err != nil) != (test.want == "") {
+			t.Errorf("PlaylistFormatFromPath(%q) = %q, %v; want %q", test.path, got, err, test.want)
+		}
+	}
+}
+
+func TestParseVideoID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"https://m.youtube.com/watch?v=abc&t=30", "abc"},
+		{"https://music.youtube.com/watch?v=abc&list=RDabc", "abc"},
+		{"https://youtu.be/abc", "abc"},
+		{"https://www.youtube.com/shorts/abc", "abc"},
+		{" https://youtu.be/abc ", "abc"},
+		{"https://www.youtube.com/playlist?list=PL123", ""},
+		{"https://example.com/watch?v=abc", ""},
+		{"not a url", ""},
+	}
+	for _, test := range tests {
+		got, ok := ParseVideoID(test.in)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("ParseVideoID(%q) = %q, %v; want %q", test.in, got, ok, test.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// PlaylistStore keeps the user's named playlists in a JSON file in the data
+// directory. Every change is written to disk straight away.
+type PlaylistStore struct {
+	path      string
+	playlists []models.Playlist
+}
+
+func LoadPlaylistStore() (*PlaylistStore, error) {
+	path, err := dataPath("playlists.json")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &PlaylistStore{path: path}
+	if err := readJSON(path, &store.playlists); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Playlists returns the playlists in the order they were created
+func (s *PlaylistStore) Playlists() []models.Playlist {
+	return s.playlists
+}
+
+func (s *PlaylistStore) Get(name string) (models.Playlist, bool) {
+	index := s.indexOf(name)
+	if index < 0 {
+		return models.Playlist{}, false
+	}
+	return s.playlists[index], true
+}
+
+func (s *PlaylistStore) Create(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("playlist name can't be empty")
+	}
+	if s.indexOf(name) >= 0 {
+		return fmt.Errorf("playlist %q already exists", name)
+	}
+
+	s.playlists = append(s.playlists, models.Playlist{
+		Name:      name,
+		CreatedAt: time.Now(),
+	})
+	return s.save()
+}
+
+// CreateSmart creates a smart playlist from the rules in query
+func (s *PlaylistStore) CreateSmart(name, query string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("playlist name can't be empty")
+	}
+	if s.indexOf(name) >= 0 {
+		return fmt.Errorf("playlist %q already exists", name)
+	}
+	if _, err := ParseSmartQuery(query); err != nil {
+		return err
+	}
+
+	s.playlists = append(s.playlists, models.Playlist{
+		Name:      name,
+		CreatedAt: time.Now(),
+		Query:     strings.TrimSpace(query),
+	})
+	return s.save()
+}
+
+// SetQuery changes the rules of a smart playlist
+func (s *PlaylistStore) SetQuery(name, query string) error {
+	index, err := s.findSmart(name, true)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("smart playlist rules can't be empty")
+	}
+	if _, err := ParseSmartQuery(query); err != nil {
+		return err
+	}
+	s.playlists[index].Query = strings.TrimSpace(query)
+	return s.save()
+}
+
+func (s *PlaylistStore) Rename(name, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("playlist name can't be empty")
+	}
+	if other := s.indexOf(newName); other >= 0 && !strings.EqualFold(name, newName) {
+		return fmt.Errorf("playlist %q already exists", newName)
+	}
+
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	s.playlists[index].Name = newName
+	return s.save()
+}
+
+func (s *PlaylistStore) Delete(name string) error {
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	s.playlists = append(s.playlists[:index], s.playlists[index+1:]...)
+	return s.save()
+}
+
+// Add appends videos to the end of a playlist
+func (s *PlaylistStore) Add(name string, videos ...models.Video) error {
+	index, err := s.findSmart(name, false)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, video := range videos {
+		s.playlists[index].Entries = append(s.playlists[index].Entries, models.PlaylistEntry{
+			Video:   video,
+			AddedAt: now,
+		})
+	}
+	return s.save()
+}
+
+// Remove deletes the entry at position from a playlist
+func (s *PlaylistStore) Remove(name string, position int) error {
+	index, err := s.findSmart(name, false)
+	if err != nil {
+		return err
+	}
+
+	entries := s.playlists[index].Entries
+	if position < 0 || position >= len(entries) {
+		return fmt.Errorf("no entry %d in playlist %q", position, name)
+	}
+	s.playlists[index].Entries = append(entries[:position], entries[position+1:]...)
+	return s.save()
+}
+
+// Move moves an entry of a playlist from one position to another
+func (s *PlaylistStore) Move(name string, from, to int) error {
+	index, err := s.findSmart(name, false)
+	if err != nil {
+		return err
+	}
+
+	entries := s.playlists[index].Entries
+	if from < 0 || from >= len(entries) || to < 0 || to >= len(entries) {
+		return fmt.Errorf("can't move entry %d to %d in playlist %q", from, to, name)
+	}
+	entry := entries[from]
+	entries = append(entries[:from], entries[from+1:]...)
+	entries = append(entries[:to], append([]models.PlaylistEntry{entry}, entries[to:]...)...)
+	s.playlists[index].Entries = entries
+	return s.save()
+}
+
+func (s *PlaylistStore) indexOf(name string) int {
+	for i, playlist := range s.playlists {
+		if strings.EqualFold(playlist.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *PlaylistStore) find(name string) (int, error) {
+	index := s.indexOf(name)
+	if index < 0 {
+		return -1, fmt.Errorf("playlist %q not found", name)
+	}
+	return index, nil
+}
+
+// findSmart finds a playlist that is a smart playlist or an ordinary one,
+// as given by smart
+func (s *PlaylistStore) findSmart(name string, smart bool) (int, error) {
+	index, err := s.find(name)
+	if err != nil {
+		return -1, err
+	}
+	if s.playlists[index].Smart() != smart {
+		if smart {
+			return -1, fmt.Errorf("playlist %q is not a smart playlist", name)
+		}
+		return -1, fmt.Errorf("playlist %q is a smart playlist, change its rules instead", name)
+	}
+	return index, nil
+}
+
+func (s *PlaylistStore) save() error {
+	return writeJSON(s.path, s.playlists)
+}
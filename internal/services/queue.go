@@ -0,0 +1,359 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// queueEntry is a track in the queue. The id tells apart entries for the same
+// video so that the original order can be restored after shuffling.
+type queueEntry struct {
+	id    int
+	video models.Video
+}
+
+// Queue is the ordered list of tracks to play and the position of the
+// current track in it
+type Queue struct {
+	items   []queueEntry
+	current int // index of the current track, -1 if nothing has been played
+	nextID  int
+
+	repeat models.RepeatMode
+	// original holds the order from before shuffling, nil when not shuffled
+	original []queueEntry
+}
+
+func NewQueue() *Queue {
+	return &Queue{current: -1, repeat: models.RepeatOff}
+}
+
+// Items returns a copy of the tracks in the queue
+func (q *Queue) Items() []models.Video {
+	videos := make([]models.Video, len(q.items))
+	for i, entry := range q.items {
+		videos[i] = entry.video
+	}
+	return videos
+}
+
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+// CurrentIndex returns the index of the current track, or -1
+func (q *Queue) CurrentIndex() int {
+	return q.current
+}
+
+func (q *Queue) Current() (models.Video, bool) {
+	if q.current < 0 || q.current >= len(q.items) {
+		return models.Video{}, false
+	}
+	return q.items[q.current].video, true
+}
+
+func (q *Queue) newEntry(video models.Video) queueEntry {
+	q.nextID++
+	return queueEntry{id: q.nextID, video: video}
+}
+
+// Append adds a track to the end of the queue. When shuffled, it goes
+// somewhere among the tracks that haven't been played yet instead.
+func (q *Queue) Append(video models.Video) {
+	entry := q.newEntry(video)
+	if q.original == nil {
+		q.items = append(q.items, entry)
+		return
+	}
+	q.original = append(q.original, entry)
+	at := q.current + 1 + rand.Intn(len(q.items)-q.current)
+	q.items = insertEntry(q.items, at, entry)
+}
+
+// InsertNext adds a track right after the current one and returns its index
+func (q *Queue) InsertNext(video models.Video) int {
+	entry := q.newEntry(video)
+	index := q.current + 1
+
+	if q.original != nil {
+		// Keep the track after the current one when the shuffle is undone too
+		at := 0
+		if q.current >= 0 {
+			at = q.originalIndex(q.items[q.current].id) + 1
+		}
+		q.original = insertEntry(q.original, at, entry)
+	}
+	q.items = insertEntry(q.items, index, entry)
+	return index
+}
+
+// Remove deletes the track at index. Removing the current track leaves the
+// queue pointing at the track before it, so that Next continues with the one
+// that followed it.
+func (q *Queue) Remove(index int) bool {
+	if index < 0 || index >= len(q.items) {
+		return false
+	}
+	if q.original != nil {
+		at := q.originalIndex(q.items[index].id)
+		q.original = append(q.original[:at], q.original[at+1:]...)
+	}
+	q.items = append(q.items[:index], q.items[index+1:]...)
+	if index <= q.current {
+		q.current--
+	}
+	return true
+}
+
+// Move moves the track at index from to index to, keeping track of the
+// current track
+func (q *Queue) Move(from, to int) bool {
+	if from < 0 || from >= len(q.items) || to < 0 || to >= len(q.items) || from == to {
+		return false
+	}
+
+	entry := q.items[from]
+	q.items = append(q.items[:from], q.items[from+1:]...)
+	q.items = insertEntry(q.items, to, entry)
+
+	switch {
+	case q.current == from:
+		q.current = to
+	case from < q.current && to >= q.current:
+		q.current--
+	case from > q.current && to <= q.current:
+		q.current++
+	}
+	return true
+}
+
+// Restore replaces the queue with videos, in that order, and makes the
+// track at current the current one. A shuffled queue keeps them in that
+// order too, which is also the order turning shuffle off goes back to.
+func (q *Queue) Restore(videos []models.Video, current int) {
+	q.items = nil
+	for _, video := range videos {
+		q.items = append(q.items, q.newEntry(video))
+	}
+	q.current = -1
+	if current >= 0 && current < len(q.items) {
+		q.current = current
+	}
+	if q.original != nil {
+		q.original = append([]queueEntry{}, q.items...)
+	}
+}
+
+// Clear removes every track from the queue
+func (q *Queue) Clear() {
+	q.items = nil
+	q.current = -1
+	if q.original != nil {
+		q.original = []queueEntry{}
+	}
+}
+
+// Select makes the track at index the current one. When shuffled, the track
+// is first moved right after the current one, so that the tracks that
+// haven't been played yet still are after it and aren't skipped.
+func (q *Queue) Select(index int) (models.Video, bool) {
+	if index < 0 || index >= len(q.items) {
+		return models.Video{}, false
+	}
+	if q.original != nil && index != q.current {
+		to := q.current + 1
+		if index < q.current {
+			// The current track moves up a place when the track leaves
+			to = q.current
+		}
+		q.Move(index, to)
+		index = to
+	}
+	return q.selectIndex(index)
+}
+
+func (q *Queue) selectIndex(index int) (models.Video, bool) {
+	if index < 0 || index >= len(q.items) {
+		return models.Video{}, false
+	}
+	q.current = index
+	return q.items[index].video, true
+}
+
+// Next advances to the track after the current one. With repeat all the
+// queue starts over after the last track, in a new order when shuffled.
+func (q *Queue) Next() (models.Video, bool) {
+	if q.current+1 < len(q.items) {
+		return q.selectIndex(q.current + 1)
+	}
+	if q.repeat != models.RepeatAll || len(q.items) == 0 {
+		return models.Video{}, false
+	}
+	if q.original != nil {
+		q.reshuffle()
+	}
+	return q.selectIndex(0)
+}
+
+// Previous goes back to the track before the current one
+func (q *Queue) Previous() (models.Video, bool) {
+	if q.current-1 >= 0 {
+		return q.selectIndex(q.current - 1)
+	}
+	if q.repeat == models.RepeatAll {
+		return q.selectIndex(len(q.items) - 1)
+	}
+	return models.Video{}, false
+}
+
+// Advance returns the track to play when the current one has finished,
+// which is the same track again with repeat one
+func (q *Queue) Advance() (models.Video, bool) {
+	if q.repeat == models.RepeatOne {
+		if video, ok := q.Current(); ok {
+			return video, true
+		}
+	}
+	return q.Next()
+}
+
+func (q *Queue) Repeat() models.RepeatMode {
+	return q.repeat
+}
+
+func (q *Queue) SetRepeat(mode models.RepeatMode) {
+	q.repeat = mode
+}
+
+// NextRepeatMode returns the mode that follows mode when cycling off, all, one
+func NextRepeatMode(mode models.RepeatMode) models.RepeatMode {
+	switch mode {
+	case models.RepeatOff:
+		return models.RepeatAll
+	case models.RepeatAll:
+		return models.RepeatOne
+	}
+	return models.RepeatOff
+}
+
+func (q *Queue) Shuffled() bool {
+	return q.original != nil
+}
+
+// SetShuffle shuffles the tracks after the current one, or restores the
+// order they had before shuffling. Playing through a shuffled queue plays
+// every track once before any is repeated.
+func (q *Queue) SetShuffle(shuffle bool) {
+	if shuffle == q.Shuffled() {
+		return
+	}
+
+	if !shuffle {
+		currentID := -1
+		if q.current >= 0 && q.current < len(q.items) {
+			currentID = q.items[q.current].id
+		}
+		q.items = q.original
+		q.original = nil
+		q.current = q.indexOf(currentID)
+		return
+	}
+
+	q.original = append([]queueEntry{}, q.items...)
+	// Tracks that have been played stay where they are
+	rest := q.items[q.current+1:]
+	rand.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+}
+
+// reshuffle puts the whole queue in a new random order for another round,
+// avoiding starting with the track that was just played
+func (q *Queue) reshuffle() {
+	lastID := q.items[q.current].id
+	rand.Shuffle(len(q.items), func(i, j int) {
+		q.items[i], q.items[j] = q.items[j], q.items[i]
+	})
+	if last := len(q.items) - 1; last > 0 && q.items[0].id == lastID {
+		q.items[0], q.items[last] = q.items[last], q.items[0]
+	}
+	q.current = -1
+}
+
+func (q *Queue) indexOf(id int) int {
+	for i, entry := range q.items {
+		if entry.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *Queue) originalIndex(id int) int {
+	for i, entry := range q.original {
+		if entry.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func insertEntry(entries []queueEntry, index int, entry queueEntry) []queueEntry {
+	entries = append(entries, queueEntry{})
+	copy(entries[index+1:], entries[index:])
+	entries[index] = entry
+	return entries
+}
+
+// Remaining returns the total length of the current track and the tracks after it
+func (q *Queue) Remaining() time.Duration {
+	var total time.Duration
+	for i := max(q.current, 0); i < len(q.items); i++ {
+		total += ParseDuration(q.items[i].video.Duration)
+	}
+	return total
+}
+
+// ParseDuration parses a video duration given either in seconds or as
+// [hours:]minutes:seconds
+func ParseDuration(dur string) time.Duration {
+	parts := strings.Split(dur, ":")
+	if len(parts) == 1 {
+		// Only seconds
+		sec, _ := strconv.Atoi(parts[0])
+		return time.Duration(sec) * time.Second
+	} else if len(parts) == 2 {
+		// Minutes:Seconds
+		min, _ := strconv.Atoi(parts[0])
+		sec, _ := strconv.Atoi(parts[1])
+		return time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	} else if len(parts) == 3 {
+		// Hours:Minutes:Seconds
+		hour, _ := strconv.Atoi(parts[0])
+		min, _ := strconv.Atoi(parts[1])
+		sec, _ := strconv.Atoi(parts[2])
+		return time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	}
+	return 0
+}
+
+// FormatDuration formats d as minutes:seconds, or as hours:minutes:seconds
+// from an hour up. ParseDuration reads it back.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
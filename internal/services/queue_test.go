@@ -0,0 +1,271 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// newTestQueue returns a queue of tracks with the given ids
+func newTestQueue(ids ...string) *Queue {
+	q := NewQueue()
+	for _, id := range ids {
+		q.Append(models.Video{ID: id, Duration: "1:00"})
+	}
+	return q
+}
+
+// queueIDs lists the ids in q, marking the current track with *
+func queueIDs(q *Queue) string {
+	var ids []string
+	for i, video := range q.Items() {
+		if i == q.CurrentIndex() {
+			ids = append(ids, "*"+video.ID)
+		} else {
+			ids = append(ids, video.ID)
+		}
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestQueueEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		selected int
+		edit     func(q *Queue)
+		want     string
+	}{
+		{"append", 0, func(q *Queue) { q.Append(models.Video{ID: "d"}) }, "*a b c d"},
+		{"insert next", 0, func(q *Queue) { q.InsertNext(models.Video{ID: "d"}) }, "*a d b c"},
+		{"insert next before playing", -1, func(q *Queue) { q.InsertNext(models.Video{ID: "d"}) }, "d a b c"},
+		{"remove after current", 0, func(q *Queue) { q.Remove(2) }, "*a b"},
+		{"remove before current", 2, func(q *Queue) { q.Remove(0) }, "b *c"},
+		{"remove current", 1, func(q *Queue) { q.Remove(1) }, "*a c"},
+		{"remove out of range", 1, func(q *Queue) { q.Remove(3) }, "a *b c"},
+		{"move current down", 0, func(q *Queue) { q.Move(0, 2) }, "b c *a"},
+		{"move past current", 1, func(q *Queue) { q.Move(0, 2) }, "*b c a"},
+		{"move before current", 1, func(q *Queue) { q.Move(2, 0) }, "c a *b"},
+		{"move out of range", 1, func(q *Queue) { q.Move(2, 3) }, "a *b c"},
+		{"clear", 1, func(q *Queue) { q.Clear() }, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newTestQueue("a", "b", "c")
+			q.Select(test.selected)
+			test.edit(q)
+			if got := queueIDs(q); got != test.want {
+				t.Errorf("queue = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueueNavigation(t *testing.T) {
+	tests := []struct {
+		repeat models.RepeatMode
+		steps  string // n for Next, p for Previous, a for Advance
+		want   string // id played after each step, - when there is none
+	}{
+		{models.RepeatOff, "nnnn", "a b c -"},
+		{models.RepeatOff, "nnpp", "a b a -"},
+		{models.RepeatAll, "nnnn", "a b c a"},
+		{models.RepeatAll, "np", "a c"},
+		{models.RepeatOne, "aaa", "a a a"},
+		{models.RepeatOne, "nan", "a a b"},
+		{models.RepeatOff, "aaaa", "a b c -"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s", test.repeat, test.steps), func(t *testing.T) {
+			q := newTestQueue("a", "b", "c")
+			q.SetRepeat(test.repeat)
+			var played []string
+			for _, step := range test.steps {
+				var video models.Video
+				var ok bool
+				switch step {
+				case 'n':
+					video, ok = q.Next()
+				case 'p':
+					video, ok = q.Previous()
+				case 'a':
+					if _, playing := q.Current(); !playing {
+						video, ok = q.Next()
+					} else {
+						video, ok = q.Advance()
+					}
+				}
+				if !ok {
+					played = append(played, "-")
+					break
+				}
+				played = append(played, video.ID)
+			}
+			if got := strings.Join(played, " "); got != test.want {
+				t.Errorf("played %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueueRemaining(t *testing.T) {
+	q := newTestQueue("a", "b", "c")
+	if got := q.Remaining(); got != 3*time.Minute {
+		t.Errorf("Remaining() before playing = %v, want 3m0s", got)
+	}
+	q.Select(1)
+	if got := q.Remaining(); got != 2*time.Minute {
+		t.Errorf("Remaining() on the second track = %v, want 2m0s", got)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"45", 45 * time.Second},
+		{"3:05", 3*time.Minute + 5*time.Second},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"", 0},
+		{"1:2:3:4", 0},
+	}
+	for _, test := range tests {
+		if got := ParseDuration(test.in); got != test.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0:00"},
+		{45 * time.Second, "0:45"},
+		{3*time.Minute + 5*time.Second, "3:05"},
+		{62*time.Minute + 3*time.Second, "1:02:03"},
+		{90*time.Second + 600*time.Millisecond, "1:31"},
+	}
+	for _, test := range tests {
+		got := FormatDuration(test.in)
+		if got != test.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", test.in, got, test.want)
+		}
+		if back := ParseDuration(got); back != test.in.Round(time.Second) {
+			t.Errorf("ParseDuration(%q) = %v, want %v", got, back, test.in.Round(time.Second))
+		}
+	}
+}
+
+// playRound plays through the rest of q and returns the ids in the order played
+func playRound(q *Queue) []string {
+	var played []string
+	for {
+		video, ok := q.Next()
+		if !ok {
+			return played
+		}
+		played = append(played, video.ID)
+	}
+}
+
+// checkOnce fails unless played holds every one of ids exactly once
+func checkOnce(t *testing.T, played []string, ids ...string) {
+	t.Helper()
+	count := make(map[string]int)
+	for _, id := range played {
+		count[id]++
+	}
+	for _, id := range ids {
+		if count[id] != 1 {
+			t.Errorf("%s played %d times in %v, want once", id, count[id], played)
+		}
+	}
+	if len(played) != len(ids) {
+		t.Errorf("played %v, want each of %v once", played, ids)
+	}
+}
+
+func TestQueueShuffle(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+	q := newTestQueue(ids...)
+	q.Next()
+	q.SetShuffle(true)
+
+	if video, _ := q.Current(); video.ID != "a" || q.CurrentIndex() != 0 {
+		t.Fatalf("current track after shuffling = %s at %d, want a at 0", video.ID, q.CurrentIndex())
+	}
+	checkOnce(t, append([]string{"a"}, playRound(q)...), ids...)
+
+	q.SetShuffle(false)
+	if got := strings.ReplaceAll(queueIDs(q), "*", ""); got != strings.Join(ids, " ") {
+		t.Errorf("order after unshuffling = %q, want %q", got, strings.Join(ids, " "))
+	}
+}
+
+func TestQueueShuffleAppend(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		q := newTestQueue("a", "b", "c", "d")
+		q.Next()
+		q.Next()
+		q.SetShuffle(true)
+		played := []string{"a"}
+		if video, ok := q.Current(); ok {
+			played = append(played, video.ID)
+		}
+		q.Append(models.Video{ID: "e"})
+		q.Append(models.Video{ID: "f"})
+
+		// Appended tracks are shuffled in with the tracks still to come
+		checkOnce(t, append(played, playRound(q)...), "a", "b", "c", "d", "e", "f")
+
+		q.SetShuffle(false)
+		if got := strings.ReplaceAll(queueIDs(q), "*", ""); got != "a b c d e f" {
+			t.Fatalf("order after unshuffling = %q, want appended tracks at the end", got)
+		}
+	}
+}
+
+func TestQueueShuffleSelect(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		q := newTestQueue("a", "b", "c", "d", "e", "f")
+		q.Next()
+		q.SetShuffle(true)
+		q.Next()
+		played := []string{"a"}
+		if video, ok := q.Current(); ok {
+			played = append(played, video.ID)
+		}
+
+		// Picking a track further down doesn't skip the ones before it
+		video, ok := q.Select(q.Len() - 1)
+		if !ok {
+			t.Fatal("Select() failed")
+		}
+		played = append(played, video.ID)
+		checkOnce(t, append(played, playRound(q)...), "a", "b", "c", "d", "e", "f")
+	}
+}
+
+func TestQueueShuffleSelectPlayed(t *testing.T) {
+	q := newTestQueue("a", "b", "c", "d")
+	q.Next()
+	q.SetShuffle(true)
+	q.Next()
+	q.Next()
+	first := q.Items()[0].ID
+
+	// Playing an earlier track again keeps the tracks after the current one to come
+	rest := len(q.Items()) - q.CurrentIndex() - 1
+	video, _ := q.Select(0)
+	if video.ID != first {
+		t.Fatalf("Select(0) = %s, want %s", video.ID, first)
+	}
+	if got := len(playRound(q)); got != rest {
+		t.Errorf("%d tracks left after replaying one, want %d", got, rest)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// radioRecentLimit is how many recently played tracks the radio won't pick again
+const radioRecentLimit = 50
+
+// radioMixSize is how many entries of a mix are fetched to pick from
+const radioMixSize = 50
+
+// Radio finds tracks related to the one playing, through the YouTube Mix
+// seeded by it, skipping recently played tracks and blocked channels and
+// keywords
+type Radio struct {
+	mu     sync.Mutex
+	recent []string // ids of recently played tracks, oldest first
+}
+
+func NewRadio() *Radio {
+	return &Radio{}
+}
+
+// Played records that a track has been played
+func (r *Radio) Played(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recent = append(r.recent, id)
+	if len(r.recent) > radioRecentLimit {
+		r.recent = r.recent[len(r.recent)-radioRecentLimit:]
+	}
+}
+
+func (r *Radio) playedRecently(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, recent := range r.recent {
+		if recent == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Related returns up to count tracks related to seed. Tracks in exclude,
+// e.g. the ones already queued, are skipped too.
+func (r *Radio) Related(seed models.Video, count int, settings models.Settings, exclude map[string]bool) ([]models.Video, error) {
+	_, mix, err := GetPlaylistPageYtDlp(context.Background(), "RD"+seed.ID, 1, radioMixSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var related []models.Video
+	seen := map[string]bool{seed.ID: true}
+	for _, video := range mix {
+		if len(related) >= count {
+			break
+		}
+		if seen[video.ID] || exclude[video.ID] || r.playedRecently(video.ID) || IsBlocked(video, settings) {
+			continue
+		}
+		seen[video.ID] = true
+		related = append(related, video)
+	}
+	return related, nil
+}
+
+// IsBlocked reports whether a video is from a blocked channel or has a
+// blocked keyword in its title
+func IsBlocked(video models.Video, settings models.Settings) bool {
+	for _, channel := range settings.BlockedChannels {
+		if strings.EqualFold(strings.TrimSpace(channel), video.Channel) {
+			return true
+		}
+	}
+
+	title := strings.ToLower(video.Title)
+	for _, keyword := range settings.BlockedKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(title, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// filterTokenPattern matches a filter in a search query, e.g. duration:<10m
+// or -channel:"Foo"
+var filterTokenPattern = regexp.MustCompile(`^(-?)(duration|after|before|channel|music|shorts|live):(.+)$`)
+
+// ParseSearchQuery splits a search query into the text to search for and
+// the filters written into it:
+//
+//	duration:<10m duration:>2m duration:2m-10m
+//	after:2024-01-01 before:2024-06-30 after:30d
+//	channel:"Foo" -channel:"Bar"
+//	music:yes shorts:no live:no
+//
+// The filters are applied on top of base.
+func ParseSearchQuery(text string, base models.SearchFilters) (string, models.SearchFilters, error) {
+	filters := base
+	filters.Channels = append([]string(nil), base.Channels...)
+	filters.ExcludeChannels = append([]string(nil), base.ExcludeChannels...)
+
+	var words []string
+	for _, token := range splitQuery(text) {
+		match := filterTokenPattern.FindStringSubmatch(token)
+		if match == nil {
+			words = append(words, token)
+			continue
+		}
+		if err := applySearchFilter(&filters, match[2], unquote(match[3]), match[1] == "-"); err != nil {
+			return "", base, err
+		}
+	}
+	return strings.Join(words, " "), filters, nil
+}
+
+func applySearchFilter(filters *models.SearchFilters, name, value string, exclude bool) error {
+	var err error
+	switch name {
+	case "duration":
+		filters.MinDuration, filters.MaxDuration, err = parseDurationRange(value, filters.MinDuration, filters.MaxDuration)
+	case "after":
+		filters.UploadedAfter, err = ParseFilterDate(value)
+	case "before":
+		filters.UploadedBefore, err = ParseFilterDate(value)
+	case "channel":
+		if exclude {
+			filters.ExcludeChannels = append(filters.ExcludeChannels, value)
+		} else {
+			filters.Channels = append(filters.Channels, value)
+		}
+	case "music":
+		filters.MusicOnly, err = parseYesNo(value)
+	case "shorts":
+		var shorts bool
+		shorts, err = parseYesNo(value)
+		filters.NoShorts = !shorts
+	case "live":
+		var live bool
+		live, err = parseYesNo(value)
+		filters.NoLive = !live
+	}
+	if err != nil {
+		return fmt.Errorf("invalid search filter %s:%s: %w", name, value, err)
+	}
+	return nil
+}
+
+// parseDurationRange parses <10m, >2m or 2m-10m, keeping the bound that
+// isn't given
+func parseDurationRange(value string, min, max time.Duration) (time.Duration, time.Duration, error) {
+	var err error
+	switch {
+	case strings.HasPrefix(value, "<"):
+		max, err = ParseSpan(strings.TrimLeft(value, "<="))
+	case strings.HasPrefix(value, ">"):
+		min, err = ParseSpan(strings.TrimLeft(value, ">="))
+	case strings.Contains(value, "-"):
+		from, to, _ := strings.Cut(value, "-")
+		if min, err = ParseSpan(from); err == nil {
+			max, err = ParseSpan(to)
+		}
+	default:
+		err = fmt.Errorf("use <10m, >2m or 2m-10m")
+	}
+	return min, max, err
+}
+
+// ParseFilterDate parses a date as YYYY-MM-DD or YYYYMMDD, or a length of
+// time back from today such as 30d, and returns it as YYYY-MM-DD
+func ParseFilterDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format("2006-01-02"), nil
+		}
+	}
+	if span, err := ParseSpan(value); err == nil {
+		return time.Now().Add(-span).Format("2006-01-02"), nil
+	}
+	return "", fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+}
+
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true", "y", "1":
+		return true, nil
+	case "no", "false", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("use yes or no")
+}
+
+// ytDlpFilterArgs returns the yt-dlp arguments that apply filters
+func ytDlpFilterArgs(filters models.SearchFilters) []string {
+	var conditions []string
+	if filters.MinDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("duration >= %d", int(filters.MinDuration.Seconds())))
+	}
+	if filters.MaxDuration > 0 {
+		conditions = append(conditions, fmt.Sprintf("duration <= %d", int(filters.MaxDuration.Seconds())))
+	}
+	if len(filters.Channels) > 0 {
+		conditions = append(conditions, fmt.Sprintf("channel ~= '%s'", channelPattern(filters.Channels)))
+	}
+	if len(filters.ExcludeChannels) > 0 {
+		conditions = append(conditions, fmt.Sprintf("channel !~= '%s'", channelPattern(filters.ExcludeChannels)))
+	}
+	if filters.MusicOnly {
+		conditions = append(conditions, "categories *= 'Music'")
+	}
+	if filters.NoShorts {
+		// Older yt-dlp versions have no media_type, shorts still have a /shorts/ URL
+		conditions = append(conditions, "media_type !=? short", "original_url !*=? /shorts/")
+	}
+	if filters.NoLive {
+		conditions = append(conditions, "!is_live")
+	}
+
+	var args []string
+	if len(conditions) > 0 {
+		// Every condition goes into one filter, separate filters are or'ed
+		args = append(args, "--match-filter", strings.Join(conditions, " & "))
+	}
+	if filters.UploadedAfter != "" {
+		args = append(args, "--dateafter", strings.ReplaceAll(filters.UploadedAfter, "-", ""))
+	}
+	if filters.UploadedBefore != "" {
+		args = append(args, "--datebefore", strings.ReplaceAll(filters.UploadedBefore, "-", ""))
+	}
+	return args
+}
+
+// channelPattern returns a case-insensitive regular expression matching
+// exactly the given channel names
+func channelPattern(channels []string) string {
+	quoted := make([]string, len(channels))
+	for i, channel := range channels {
+		// Quotes can't be escaped in a filter value, and & separates conditions
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(channel), "'", ".")
+		quoted[i] = strings.ReplaceAll(quoted[i], "&", `\&`)
+	}
+	return "(?i)^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// splitQuery splits text at spaces, keeping double quoted parts together
+func splitQuery(text string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			token.WriteRune(r)
+		case r == ' ' && !quoted:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+func unquote(value string) string {
+	return strings.Trim(value, `"`)
+}
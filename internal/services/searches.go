@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// MaxSearchHistory is how many past searches are kept
+const MaxSearchHistory = 500
+
+// maxSeenResults is how many results a saved search remembers having shown
+const maxSeenResults = 2000
+
+// SearchStore keeps the past searches and the saved searches in a JSON file
+// in the data directory. Every change is written to disk straight away.
+type SearchStore struct {
+	path string
+	data searchData
+}
+
+type searchData struct {
+	History []string             `json:"history"` // oldest first
+	Saved   []models.SavedSearch `json:"saved"`
+}
+
+func LoadSearchStore() (*SearchStore, error) {
+	path, err := dataPath("searches.json")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &SearchStore{path: path}
+	if err := readJSON(path, &store.data); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return store, nil
+}
+
+// History returns the past searches, oldest first
+func (s *SearchStore) History() []string {
+	return append([]string(nil), s.data.History...)
+}
+
+// Record adds a search to the end of the history, moving it there if it was
+// made before
+func (s *SearchStore) Record(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	history := s.data.History[:0]
+	for _, past := range s.data.History {
+		if past != query {
+			history = append(history, past)
+		}
+	}
+	history = append(history, query)
+	if len(history) > MaxSearchHistory {
+		history = history[len(history)-MaxSearchHistory:]
+	}
+	s.data.History = history
+	return s.save()
+}
+
+// Saved returns the saved searches in the order they were created
+func (s *SearchStore) Saved() []models.SavedSearch {
+	return s.data.Saved
+}
+
+func (s *SearchStore) GetSaved(name string) (models.SavedSearch, bool) {
+	index := s.indexOf(name)
+	if index < 0 {
+		return models.SavedSearch{}, false
+	}
+	return s.data.Saved[index], true
+}
+
+// SaveSearch saves query under name
+func (s *SearchStore) SaveSearch(name, query string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("saved search name can't be empty")
+	}
+	if s.indexOf(name) >= 0 {
+		return fmt.Errorf("saved search %q already exists", name)
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("nothing to search for")
+	}
+
+	s.data.Saved = append(s.data.Saved, models.SavedSearch{
+		Name:      name,
+		Query:     query,
+		CreatedAt: time.Now(),
+	})
+	return s.save()
+}
+
+// SetSavedQuery changes the query of a saved search. Its results so far are
+// forgotten, as they were found by the old query.
+func (s *SearchStore) SetSavedQuery(name, query string) error {
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("nothing to search for")
+	}
+	if query == s.data.Saved[index].Query {
+		return nil
+	}
+
+	search := &s.data.Saved[index]
+	search.Query = query
+	search.LastRun = time.Time{}
+	search.Seen = nil
+	return s.save()
+}
+
+func (s *SearchStore) RenameSaved(name, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("saved search name can't be empty")
+	}
+	if other := s.indexOf(newName); other >= 0 && !strings.EqualFold(name, newName) {
+		return fmt.Errorf("saved search %q already exists", newName)
+	}
+
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	s.data.Saved[index].Name = newName
+	return s.save()
+}
+
+func (s *SearchStore) DeleteSaved(name string) error {
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	s.data.Saved = append(s.data.Saved[:index], s.data.Saved[index+1:]...)
+	return s.save()
+}
+
+// StartRun records that a saved search is being run again and returns the
+// ids of the results it has shown before, results not among them are new.
+// It returns nil if the search hasn't shown anything yet.
+func (s *SearchStore) StartRun(name string) (map[string]bool, error) {
+	index, err := s.find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &s.data.Saved[index]
+	var seen map[string]bool
+	if len(search.Seen) > 0 {
+		seen = make(map[string]bool, len(search.Seen))
+		for _, id := range search.Seen {
+			seen[id] = true
+		}
+	}
+	search.LastRun = time.Now()
+	return seen, s.save()
+}
+
+// MarkSeen records that a saved search has shown the results with ids
+func (s *SearchStore) MarkSeen(name string, ids ...string) error {
+	index, err := s.find(name)
+	if err != nil {
+		return err
+	}
+
+	search := &s.data.Saved[index]
+	seen := make(map[string]bool, len(search.Seen))
+	for _, id := range search.Seen {
+		seen[id] = true
+	}
+	before := len(search.Seen)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			search.Seen = append(search.Seen, id)
+		}
+	}
+	if len(search.Seen) == before {
+		return nil
+	}
+	if len(search.Seen) > maxSeenResults {
+		search.Seen = search.Seen[len(search.Seen)-maxSeenResults:]
+	}
+	return s.save()
+}
+
+func (s *SearchStore) indexOf(name string) int {
+	for i, search := range s.data.Saved {
+		if strings.EqualFold(search.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *SearchStore) find(name string) (int, error) {
+	index := s.indexOf(name)
+	if index < 0 {
+		return -1, fmt.Errorf("saved search %q not found", name)
+	}
+	return index, nil
+}
+
+func (s *SearchStore) save() error {
+	return writeJSON(s.path, s.data)
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// LoadSession reads the session saved when ytview last exited. A missing
+// session is empty, not an error.
+func LoadSession() (models.Session, error) {
+	session := models.Session{Current: -1}
+
+	path, err := dataPath("session.json")
+	if err != nil {
+		return session, err
+	}
+	if err := readJSON(path, &session); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return models.Session{Current: -1}, err
+	}
+	return session, nil
+}
+
+func SaveSession(session models.Session) error {
+	path, err := dataPath("session.json")
+	if err != nil {
+		return err
+	}
+	session.SavedAt = time.Now()
+	return writeJSON(path, session)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// MaxPageSize is the most search or trending results that can be loaded at a time
+const MaxPageSize = 100
+
+// DefaultSettings returns the settings used before anything has been saved
+func DefaultSettings() models.Settings {
+	return models.Settings{
+		Volume:   100,
+		Repeat:   models.RepeatOff,
+		PageSize: 10,
+	}
+}
+
+func settingsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "settings.json"), nil
+}
+
+// LoadSettings reads the saved settings, falling back to the defaults for a
+// missing file or missing fields
+func LoadSettings() (models.Settings, error) {
+	settings := DefaultSettings()
+
+	path, err := settingsPath()
+	if err != nil {
+		return settings, err
+	}
+
+	if err := readJSON(path, &settings); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return DefaultSettings(), err
+	}
+	if settings.PageSize < 1 || settings.PageSize > MaxPageSize {
+		settings.PageSize = DefaultSettings().PageSize
+	}
+	return settings, nil
+}
+
+func SaveSettings(settings models.Settings) error {
+	path, err := settingsPath()
+	if err != nil {
+		return err
+	}
+	return writeJSON(path, settings)
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// minPlayTime is how long a skipped track has to be listened to for it to
+// count as played
+const minPlayTime = 30 * time.Second
+
+// TrackStats is what is known locally about a track: how often and when it
+// was played, and what the user recorded about it
+type TrackStats struct {
+	Video      models.Video
+	Plays      int
+	LastPlayed time.Time // zero if the track was never played
+	Info       models.TrackInfo
+}
+
+// SmartQuery is a parsed smart playlist definition, e.g.
+//
+//	rating >= 4 and played > 30d
+//	channel = "Lofi Girl" duration < 5m
+//	since:month sort:plays limit:25
+//
+// Conditions compare a field with a value and must all hold. Options set
+// the order, the number of tracks and the period plays are counted over.
+// With a period, only the tracks played during it are included.
+type SmartQuery struct {
+	conditions []smartCondition
+	sort       string
+	limit      int
+	since      time.Time
+}
+
+type smartCondition struct {
+	field string
+	op    string
+	value string
+	span  time.Duration // value of the duration and played fields
+	num   int           // value of the rating and plays fields
+}
+
+// smartFields maps each field to the operators it supports
+var smartFields = map[string][]string{
+	"rating":   {"=", "!=", "<", "<=", ">", ">="},
+	"plays":    {"=", "!=", "<", "<=", ">", ">="},
+	"played":   {"<", "<=", ">", ">="},
+	"duration": {"=", "!=", "<", "<=", ">", ">="},
+	"channel":  {"=", "!=", "~"},
+	"title":    {"=", "!=", "~"},
+	"tag":      {"=", "!="},
+	"favorite": {"=", "!="},
+}
+
+var smartSorts = []string{"played", "plays", "rating", "duration", "title"}
+
+// ParseSmartQuery parses the definition of a smart playlist
+func ParseSmartQuery(text string) (*SmartQuery, error) {
+	query := &SmartQuery{sort: "played"}
+	s := &smartScanner{text: text}
+	for {
+		word := s.word()
+		if word == "" {
+			if !s.done() {
+				return nil, fmt.Errorf("unexpected %q", s.rest())
+			}
+			break
+		}
+		if strings.EqualFold(word, "and") {
+			continue
+		}
+
+		if s.accept(":") {
+			if err := query.setOption(strings.ToLower(word), s.value()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		cond := smartCondition{field: strings.ToLower(word), op: s.operator(), value: s.value()}
+		ops, ok := smartFields[cond.field]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", word)
+		}
+		if !containsString(ops, cond.op) {
+			return nil, fmt.Errorf("%s can't be compared with %q", cond.field, cond.op)
+		}
+		if cond.value == "" {
+			return nil, fmt.Errorf("missing value for %s", cond.field)
+		}
+		if err := cond.parseValue(); err != nil {
+			return nil, err
+		}
+		query.conditions = append(query.conditions, cond)
+	}
+	return query, nil
+}
+
+func (q *SmartQuery) setOption(name, value string) error {
+	switch name {
+	case "sort":
+		if !containsString(smartSorts, value) {
+			return fmt.Errorf("can't sort by %q, use one of %s", value, strings.Join(smartSorts, ", "))
+		}
+		q.sort = value
+	case "limit":
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid limit %q", value)
+		}
+		q.limit = limit
+	case "since":
+		since, err := parseSince(value, time.Now())
+		if err != nil {
+			return err
+		}
+		q.since = since
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return nil
+}
+
+func (c *smartCondition) parseValue() error {
+	var err error
+	switch c.field {
+	case "rating", "plays":
+		c.num, err = strconv.Atoi(c.value)
+	case "duration", "played":
+		c.span, err = ParseSpan(c.value)
+	case "favorite":
+		switch strings.ToLower(c.value) {
+		case "yes", "true":
+			c.value = "yes"
+		case "no", "false":
+			c.value = "no"
+		default:
+			err = fmt.Errorf("use yes or no")
+		}
+	case "tag":
+		c.value = strings.ToLower(c.value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", c.value, c.field, err)
+	}
+	return nil
+}
+
+func (c smartCondition) match(track TrackStats, now time.Time) bool {
+	switch c.field {
+	case "rating":
+		return compareInt(track.Info.Rating, c.op, c.num)
+	case "plays":
+		return compareInt(track.Plays, c.op, c.num)
+	case "duration":
+		duration := ParseDuration(track.Video.Duration)
+		return duration > 0 && compareInt(int(duration), c.op, int(c.span))
+	case "played":
+		// A track that was never played was played infinitely long ago
+		if track.LastPlayed.IsZero() {
+			return c.op == ">" || c.op == ">="
+		}
+		return compareInt(int(now.Sub(track.LastPlayed)), c.op, int(c.span))
+	case "channel":
+		return compareText(track.Video.Channel, c.op, c.value)
+	case "title":
+		return compareText(track.Video.Title, c.op, c.value)
+	case "tag":
+		return track.Info.HasTags(c.value) == (c.op == "=")
+	case "favorite":
+		return track.Info.Favorite == (c.value == "yes") == (c.op == "=")
+	}
+	return false
+}
+
+// Since returns the start of the period plays are counted over, zero for all time
+func (q *SmartQuery) Since() time.Time {
+	return q.since
+}
+
+// Evaluate returns the tracks that match the query, in its order
+func (q *SmartQuery) Evaluate(tracks []TrackStats) []models.Video {
+	now := time.Now()
+	var matched []TrackStats
+	for _, track := range tracks {
+		// With a period, tracks that weren't played during it are left out
+		ok := q.since.IsZero() || track.Plays > 0
+		for _, cond := range q.conditions {
+			if !cond.match(track, now) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, track)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		switch q.sort {
+		case "plays":
+			return a.Plays > b.Plays
+		case "rating":
+			return a.Info.Rating > b.Info.Rating
+		case "duration":
+			return ParseDuration(a.Video.Duration) < ParseDuration(b.Video.Duration)
+		case "title":
+			return strings.ToLower(a.Video.Title) < strings.ToLower(b.Video.Title)
+		}
+		return a.LastPlayed.After(b.LastPlayed)
+	})
+	if q.limit > 0 && len(matched) > q.limit {
+		matched = matched[:q.limit]
+	}
+
+	videos := make([]models.Video, len(matched))
+	for i, track := range matched {
+		videos[i] = track.Video
+	}
+	return videos
+}
+
+// CollectTrackStats gathers every track that was played or is in the
+// library. Only plays from since onwards are counted.
+func CollectTrackStats(history []models.HistoryEntry, library []models.TrackInfo, since time.Time) []TrackStats {
+	var tracks []TrackStats
+	index := make(map[string]int)
+	track := func(video models.Video) *TrackStats {
+		i, ok := index[video.ID]
+		if !ok {
+			i = len(tracks)
+			index[video.ID] = i
+			tracks = append(tracks, TrackStats{Video: video})
+		}
+		return &tracks[i]
+	}
+
+	for _, entry := range history {
+		t := track(entry.Video)
+		t.Video = entry.Video
+		if entry.StartedAt.After(t.LastPlayed) {
+			t.LastPlayed = entry.StartedAt
+		}
+		counts := entry.Outcome == models.HistoryFinished || entry.Listened >= minPlayTime
+		if counts && !entry.StartedAt.Before(since) {
+			t.Plays++
+		}
+	}
+	for _, info := range library {
+		track(info.Video).Info = info
+	}
+	return tracks
+}
+
+// EvaluateSmartPlaylist returns the tracks a smart playlist holds right now
+func EvaluateSmartPlaylist(query string, history *HistoryStore, library *LibraryStore) ([]models.Video, error) {
+	q, err := ParseSmartQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.HistoryEntry
+	if history != nil {
+		entries = history.Entries()
+	}
+	var infos []models.TrackInfo
+	if library != nil {
+		infos = library.Tracks()
+	}
+	return q.Evaluate(CollectTrackStats(entries, infos, q.Since())), nil
+}
+
+// ParseSpan parses a length of time such as 90s, 5m, 1h30m, 30d, 2w or 3:30
+func ParseSpan(text string) (time.Duration, error) {
+	if strings.Contains(text, ":") {
+		return ParseDuration(text), nil
+	}
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"d", 24 * time.Hour}, {"w", 7 * 24 * time.Hour}} {
+		if n, ok := strings.CutSuffix(text, unit.suffix); ok {
+			days, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid length %q", text)
+			}
+			return time.Duration(days * float64(unit.size)), nil
+		}
+	}
+	span, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", text)
+	}
+	return span, nil
+}
+
+// parseSince parses the start of a period: today, week, month, year or a
+// length of time back from now
+func parseSince(text string, now time.Time) (time.Time, error) {
+	y, m, d := now.Date()
+	switch strings.ToLower(text) {
+	case "today":
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	case "week":
+		return time.Date(y, m, d-(int(now.Weekday())+6)%7, 0, 0, 0, 0, now.Location()), nil
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location()), nil
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	span, err := ParseSpan(text)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-span), nil
+}
+
+func compareInt(a int, op string, b int) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func compareText(a, op, b string) bool {
+	switch op {
+	case "=":
+		return strings.EqualFold(a, b)
+	case "!=":
+		return !strings.EqualFold(a, b)
+	case "~":
+		return strings.Contains(strings.ToLower(a), strings.ToLower(b))
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// smartScanner splits a smart playlist definition into words, operators
+// and values
+type smartScanner struct {
+	text string
+	pos  int
+}
+
+// peek returns the character at the current position and its size in bytes
+func (s *smartScanner) peek() (rune, int) {
+	return utf8.DecodeRuneInString(s.text[s.pos:])
+}
+
+func (s *smartScanner) skipSpace() {
+	for s.pos < len(s.text) {
+		c, size := s.peek()
+		if !unicode.IsSpace(c) {
+			break
+		}
+		s.pos += size
+	}
+}
+
+func (s *smartScanner) done() bool {
+	s.skipSpace()
+	return s.pos >= len(s.text)
+}
+
+func (s *smartScanner) rest() string {
+	return s.text[s.pos:]
+}
+
+// word reads a field or option name
+func (s *smartScanner) word() string {
+	s.skipSpace()
+	start := s.pos
+	for s.pos < len(s.text) {
+		c, size := s.peek()
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			break
+		}
+		s.pos += size
+	}
+	return s.text[start:s.pos]
+}
+
+func (s *smartScanner) accept(token string) bool {
+	s.skipSpace()
+	if strings.HasPrefix(s.text[s.pos:], token) {
+		s.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (s *smartScanner) operator() string {
+	for _, op := range []string{">=", "<=", "!=", "=", "<", ">", "~"} {
+		if s.accept(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// value reads a bare word or a double quoted string
+func (s *smartScanner) value() string {
+	s.skipSpace()
+	if s.accept(`"`) {
+		end := strings.IndexByte(s.text[s.pos:], '"')
+		if end < 0 {
+			end = len(s.text) - s.pos
+		}
+		value := s.text[s.pos : s.pos+end]
+		s.pos = min(s.pos+end+1, len(s.text))
+		return value
+	}
+	start := s.pos
+	for s.pos < len(s.text) {
+		c, size := s.peek()
+		if unicode.IsSpace(c) {
+			break
+		}
+		s.pos += size
+	}
+	return s.text[start:s.pos]
+}
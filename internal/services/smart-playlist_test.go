@@ -0,0 +1,230 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+func TestParseSpan(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"90s", 90 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"30d", 30 * day, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"2w", 14 * day, false},
+		{"3:30", 3*time.Minute + 30*time.Second, false},
+		{"1:00:00", time.Hour, false},
+		{"", 0, true},
+		{"5", 0, true},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParseSpan(test.in)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParseSpan(%q) = %v, %v; want %v (error %v)", test.in, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	// A Thursday afternoon
+	now := time.Date(2024, 5, 16, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"today", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{"Month", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"year", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30d", now.Add(-30 * 24 * time.Hour)},
+		{"2h", now.Add(-2 * time.Hour)},
+	}
+	for _, test := range tests {
+		got, err := parseSince(test.in, now)
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", test.in, got, err, test.want)
+		}
+	}
+
+	// Weeks start on Monday, even on a Sunday
+	sunday := time.Date(2024, 5, 19, 10, 0, 0, 0, time.UTC)
+	if got, _ := parseSince("week", sunday); !got.Equal(time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseSince(week) on a Sunday = %v, want the Monday before", got)
+	}
+	if _, err := parseSince("fortnight", now); err == nil {
+		t.Error("parseSince(fortnight) succeeded, want an error")
+	}
+}
+
+func TestParseSmartQuery(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string // part of the error, empty if the query is valid
+	}{
+		{"rating >= 4 and played > 30d", ""},
+		{`channel = "Lofi Girl" duration < 5m`, ""},
+		{"since:month sort:plays limit:25", ""},
+		{"tag = Chill AND favorite = yes", ""},
+		{"title ~ remix", ""},
+		{"channel = Voilà", ""},
+		{`channel = "Sigur Rós" title ~ Hoppípolla`, ""},
+		{"", ""},
+		{"mood = happy", "unknown field"},
+		{"played = 3d", "can't be compared"},
+		{"tag ~ chill", "can't be compared"},
+		{"rating >=", "missing value"},
+		{"rating >= four", "invalid value"},
+		{"duration < long", "invalid value"},
+		{"favorite = maybe", "invalid value"},
+		{"sort:random", "can't sort"},
+		{"limit:0", "invalid limit"},
+		{"since:forever", "invalid length"},
+		{"colour:red", "unknown option"},
+		{"rating >= 4 !", "unexpected"},
+	}
+	for _, test := range tests {
+		_, err := ParseSmartQuery(test.in)
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("ParseSmartQuery(%q): %v", test.in, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("ParseSmartQuery(%q) error = %v, want %q", test.in, err, test.wantErr)
+		}
+	}
+}
+
+// testTracks returns tracks a to e with various stats
+func testTracks(now time.Time) []TrackStats {
+	day := 24 * time.Hour
+	return []TrackStats{
+		{
+			Video: models.Video{ID: "a", Title: "Alpha", Channel: "Lofi Girl", Duration: "3:00"},
+			Plays: 5, LastPlayed: now.Add(-2 * day),
+			Info: models.TrackInfo{Rating: 5, Favorite: true, Tags: []string{"chill", "study"}},
+		},
+		{
+			Video: models.Video{ID: "b", Title: "bravo (Remix)", Channel: "Sigur Rós", Duration: "7:30"},
+			Plays: 2, LastPlayed: now.Add(-40 * day),
+			Info: models.TrackInfo{Rating: 3},
+		},
+		{
+			Video: models.Video{ID: "c", Title: "Charlie", Channel: "Lofi Girl", Duration: "2:00"},
+			Plays: 9, LastPlayed: now.Add(-time.Hour),
+			Info: models.TrackInfo{Rating: 4, Tags: []string{"chill"}},
+		},
+		{
+			Video: models.Video{ID: "d", Title: "Delta", Channel: "Voilà", Duration: ""},
+			Info:  models.TrackInfo{Favorite: true},
+		},
+		{
+			Video: models.Video{ID: "e", Title: "echo", Channel: "Other"},
+			Plays: 1, LastPlayed: now.Add(-10 * day),
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // ids in order
+	}{
+		{"", "c a e b d"},
+		{"rating >= 4", "c a"},
+		{"rating >= 4 sort:title", "a c"},
+		{"played > 30d", "b d"},
+		{"played < 1w", "c a"},
+		{`channel = "lofi girl"`, "c a"},
+		{"channel != Voilà", "c a e b"},
+		{"channel = Voilà", "d"},
+		{`channel ~ "Rós"`, "b"},
+		{"title ~ remix", "b"},
+		{"tag = chill", "c a"},
+		{"tag != chill favorite = yes", "d"},
+		{"favorite = no sort:plays", "c b e"},
+		{"duration < 5m", "c a"},
+		{"duration >= 5m", "b"},
+		{"plays >= 2 sort:plays limit:2", "c a"},
+		{"sort:duration", "d e c a b"}, // unknown lengths count as 0
+		{"sort:rating", "a c b d e"},
+	}
+	now := time.Now()
+	for _, test := range tests {
+		query, err := ParseSmartQuery(test.query)
+		if err != nil {
+			t.Fatalf("ParseSmartQuery(%q): %v", test.query, err)
+		}
+		var ids []string
+		for _, video := range query.Evaluate(testTracks(now)) {
+			ids = append(ids, video.ID)
+		}
+		if got := strings.Join(ids, " "); got != test.want {
+			t.Errorf("%q matched %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestEvaluateSince(t *testing.T) {
+	now := time.Now()
+	history := []models.HistoryEntry{
+		{Video: models.Video{ID: "old"}, StartedAt: now.Add(-60 * 24 * time.Hour), Outcome: models.HistoryFinished},
+		{Video: models.Video{ID: "new"}, StartedAt: now.Add(-time.Hour), Outcome: models.HistoryFinished},
+		{Video: models.Video{ID: "new"}, StartedAt: now.Add(-2 * time.Hour), Outcome: models.HistoryFinished},
+		{Video: models.Video{ID: "skipped"}, StartedAt: now.Add(-time.Hour), Outcome: models.HistorySkipped, Listened: 5 * time.Second},
+		{Video: models.Video{ID: "listened"}, StartedAt: now.Add(-time.Hour), Outcome: models.HistorySkipped, Listened: time.Minute},
+	}
+	library := []models.TrackInfo{{Video: models.Video{ID: "rated"}, Rating: 5}}
+
+	query, err := ParseSmartQuery("since:30d sort:plays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, video := range query.Evaluate(CollectTrackStats(history, library, query.Since())) {
+		ids = append(ids, video.ID)
+	}
+	// Tracks not played during the period, or only skipped, are left out
+	if got := strings.Join(ids, " "); got != "new listened" {
+		t.Errorf("since:30d sort:plays matched %q, want \"new listened\"", got)
+	}
+}
+
+func TestCollectTrackStats(t *testing.T) {
+	now := time.Now()
+	first := now.Add(-3 * time.Hour)
+	last := now.Add(-time.Hour)
+	history := []models.HistoryEntry{
+		{Video: models.Video{ID: "a", Title: "Old title"}, StartedAt: first, Outcome: models.HistoryFinished},
+		{Video: models.Video{ID: "a", Title: "New title"}, StartedAt: last, Outcome: models.HistoryFailed},
+		{Video: models.Video{ID: "b"}, StartedAt: first, Outcome: models.HistoryFinished},
+	}
+	library := []models.TrackInfo{
+		{Video: models.Video{ID: "b"}, Rating: 4},
+		{Video: models.Video{ID: "c"}, Favorite: true},
+	}
+
+	tracks := CollectTrackStats(history, library, time.Time{})
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(tracks))
+	}
+	a, b, c := tracks[0], tracks[1], tracks[2]
+	if a.Plays != 1 || !a.LastPlayed.Equal(last) || a.Video.Title != "New title" {
+		t.Errorf("a = %d plays, last %v, %q; want 1 play, last %v, \"New title\"", a.Plays, a.LastPlayed, a.Video.Title, last)
+	}
+	if b.Plays != 1 || b.Info.Rating != 4 {
+		t.Errorf("b = %d plays, rating %d; want 1 play, rating 4", b.Plays, b.Info.Rating)
+	}
+	if c.Plays != 0 || !c.LastPlayed.IsZero() || !c.Info.Favorite {
+		t.Errorf("c = %d plays, last %v, favorite %v; want an unplayed favorite", c.Plays, c.LastPlayed, c.Info.Favorite)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// SubscriptionCheckSize is how many of the newest uploads of each channel a
+// refresh looks at
+const SubscriptionCheckSize = 15
+
+// maxChannelUploads is how many uploads of a channel are remembered, so that
+// they aren't found again as new
+const maxChannelUploads = 200
+
+// SubscriptionStore keeps the subscribed channels and the uploads found on
+// them in a JSON file in the data directory. Every change is written to disk
+// straight away. It is safe to use from several goroutines, as refreshes
+// run in the background.
+type SubscriptionStore struct {
+	mu   sync.Mutex
+	path string
+	data subscriptionData
+}
+
+type subscriptionData struct {
+	Channels []models.Subscription `json:"channels"`
+	Uploads  []models.Upload       `json:"uploads"` // oldest found first
+}
+
+func LoadSubscriptionStore() (*SubscriptionStore, error) {
+	path, err := dataPath("subscriptions.json")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &SubscriptionStore{path: path}
+	if err := readJSON(path, &store.data); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Subscriptions returns the subscribed channels in the order they were added
+func (s *SubscriptionStore) Subscriptions() []models.Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Subscription(nil), s.data.Channels...)
+}
+
+// Subscribed reports whether the channel at url is subscribed to
+func (s *SubscriptionStore) Subscribed(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.indexOf(url) >= 0
+}
+
+// Subscribe adds a channel. Its current uploads count as seen once the
+// first refresh has found them.
+func (s *SubscriptionStore) Subscribe(channel models.Channel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if channel.URL == "" {
+		return fmt.Errorf("channel %q has no URL", channel.Name)
+	}
+	if s.indexOf(channel.URL) >= 0 {
+		return fmt.Errorf("already subscribed to %q", channel.Name)
+	}
+	s.data.Channels = append(s.data.Channels, models.Subscription{
+		Channel:      channel,
+		SubscribedAt: time.Now(),
+	})
+	return s.save()
+}
+
+// Unsubscribe removes the channel at url and the uploads found on it
+func (s *SubscriptionStore) Unsubscribe(url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.indexOf(url)
+	if index < 0 {
+		return fmt.Errorf("not subscribed to %q", url)
+	}
+	s.data.Channels = append(s.data.Channels[:index], s.data.Channels[index+1:]...)
+
+	uploads := s.data.Uploads[:0]
+	for _, upload := range s.data.Uploads {
+		if upload.Channel != url {
+			uploads = append(uploads, upload)
+		}
+	}
+	s.data.Uploads = uploads
+	return s.save()
+}
+
+// Unseen returns the uploads that haven't been seen yet, the most recently
+// found first
+func (s *SubscriptionStore) Unseen() []models.Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var unseen []models.Upload
+	for _, upload := range s.data.Uploads {
+		if !upload.Seen {
+			unseen = append(unseen, upload)
+		}
+	}
+	// Uploads found together stay newest first, as the channel lists them
+	sort.SliceStable(unseen, func(i, j int) bool {
+		return unseen[i].FoundAt.After(unseen[j].FoundAt)
+	})
+	return unseen
+}
+
+// UnseenCount returns how many uploads haven't been seen yet
+func (s *SubscriptionStore) UnseenCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, upload := range s.data.Uploads {
+		if !upload.Seen {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkSeen marks the uploads of the videos with ids as seen. Videos that
+// aren't uploads of a subscribed channel are ignored.
+func (s *SubscriptionStore) MarkSeen(ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		marked[id] = true
+	}
+	changed := false
+	for i := range s.data.Uploads {
+		if upload := &s.data.Uploads[i]; !upload.Seen && marked[upload.Video.ID] {
+			upload.Seen = true
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// AddUploads records the newest uploads of the channel at url, listed newest
+// first, and returns how many of them are new. On the first refresh of a
+// channel they all count as seen.
+func (s *SubscriptionStore) AddUploads(url string, videos []models.Video) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.indexOf(url)
+	if index < 0 {
+		// Unsubscribed while the refresh was running
+		return 0, nil
+	}
+	first := s.data.Channels[index].CheckedAt.IsZero()
+	s.data.Channels[index].CheckedAt = time.Now()
+
+	known := make(map[string]bool)
+	for _, upload := range s.data.Uploads {
+		if upload.Channel == url {
+			known[upload.Video.ID] = true
+		}
+	}
+
+	// Append the oldest first so that the uploads stay in the order found
+	added := 0
+	now := time.Now()
+	for i := len(videos) - 1; i >= 0; i-- {
+		video := videos[i]
+		if video.ID == "" || known[video.ID] {
+			continue
+		}
+		known[video.ID] = true
+		s.data.Uploads = append(s.data.Uploads, models.Upload{
+			Video:   video,
+			Channel: url,
+			FoundAt: now,
+			Seen:    first,
+		})
+		if !first {
+			added++
+		}
+	}
+	s.trimUploads(url)
+	return added, s.save()
+}
+
+// trimUploads forgets the oldest seen uploads of the channel at url beyond
+// maxChannelUploads
+func (s *SubscriptionStore) trimUploads(url string) {
+	count := 0
+	for _, upload := range s.data.Uploads {
+		if upload.Channel == url {
+			count++
+		}
+	}
+
+	uploads := s.data.Uploads[:0]
+	for _, upload := range s.data.Uploads {
+		if count > maxChannelUploads && upload.Channel == url && upload.Seen {
+			count--
+			continue
+		}
+		uploads = append(uploads, upload)
+	}
+	s.data.Uploads = uploads
+}
+
+func (s *SubscriptionStore) indexOf(url string) int {
+	for i, subscription := range s.data.Channels {
+		if strings.EqualFold(subscription.Channel.URL, url) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *SubscriptionStore) save() error {
+	return writeJSON(s.path, s.data)
+}
+
+// RefreshSubscriptions looks for new uploads on every subscribed channel,
+// one after the other, and returns how many were found. A channel that
+// can't be checked doesn't stop the others; the first error is returned.
+func RefreshSubscriptions(ctx context.Context, store *SubscriptionStore) (int, error) {
+	added := 0
+	var firstErr error
+	for _, subscription := range store.Subscriptions() {
+		var videos []models.Video
+		err := StreamChannelVideosYtDlp(ctx, subscription.Channel, false, 1, SubscriptionCheckSize, func(video models.Video) {
+			videos = append(videos, video)
+		})
+		if ctx.Err() != nil {
+			return added, ctx.Err()
+		}
+		if err == nil {
+			var n int
+			n, err = store.AddUploads(subscription.Channel.URL, videos)
+			added += n
+		}
+		if err != nil {
+			log.Printf("Error refreshing %q: %v\n", subscription.Channel.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", subscription.Channel.Name, err)
+			}
+		}
+	}
+	return added, firstErr
+}
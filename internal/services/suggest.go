@@ -0,0 +1,199 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// suggestDelay is how long typing has to pause before YouTube is asked for
+// suggestions
+const suggestDelay = 300 * time.Millisecond
+
+// suggestCacheLimit is how many prefixes YouTube suggestions are kept for
+const suggestCacheLimit = 500
+
+// SuggestionSource offers completions for the text typed so far
+type SuggestionSource interface {
+	Suggestions(prefix string) []string
+}
+
+// SuggestFunc turns a function into a SuggestionSource
+type SuggestFunc func(prefix string) []string
+
+func (f SuggestFunc) Suggestions(prefix string) []string {
+	return f(prefix)
+}
+
+// ListSuggestions offers the entries of a list that contain the prefix,
+// those that start with it first. list is called on every lookup so that it
+// is always up to date.
+func ListSuggestions(list func() []string) SuggestionSource {
+	return SuggestFunc(func(prefix string) []string {
+		prefix = strings.ToLower(strings.TrimSpace(prefix))
+		if prefix == "" {
+			return nil
+		}
+		var starts, contains []string
+		for _, entry := range list() {
+			lower := strings.ToLower(entry)
+			if strings.HasPrefix(lower, prefix) {
+				starts = append(starts, entry)
+			} else if strings.Contains(lower, prefix) {
+				contains = append(contains, entry)
+			}
+		}
+		return append(starts, contains...)
+	})
+}
+
+// LimitSuggestions keeps a source from offering more than limit suggestions
+func LimitSuggestions(source SuggestionSource, limit int) SuggestionSource {
+	return SuggestFunc(func(prefix string) []string {
+		suggestions := source.Suggestions(prefix)
+		if len(suggestions) > limit {
+			suggestions = suggestions[:limit]
+		}
+		return suggestions
+	})
+}
+
+// Suggester merges the suggestions of several sources, in the order the
+// sources were added, leaving out duplicates
+type Suggester struct {
+	sources []SuggestionSource
+	limit   int
+}
+
+func NewSuggester(limit int, sources ...SuggestionSource) *Suggester {
+	return &Suggester{sources: sources, limit: limit}
+}
+
+// Add adds a source after the existing ones
+func (s *Suggester) Add(source SuggestionSource) {
+	s.sources = append(s.sources, source)
+}
+
+// Suggestions returns at most limit suggestions for prefix
+func (s *Suggester) Suggestions(prefix string) []string {
+	var suggestions []string
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(prefix)): true}
+	for _, source := range s.sources {
+		for _, suggestion := range source.Suggestions(prefix) {
+			key := strings.ToLower(strings.TrimSpace(suggestion))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			suggestions = append(suggestions, suggestion)
+			if len(suggestions) >= s.limit {
+				return suggestions
+			}
+		}
+	}
+	return suggestions
+}
+
+// YouTubeSuggestions offers the completions of the YouTube search box. A
+// lookup only returns what is cached, and asks YouTube once typing pauses,
+// calling onUpdate with the prefix when the answer arrives.
+type YouTubeSuggestions struct {
+	client   *http.Client
+	onUpdate func(prefix string)
+
+	mu      sync.Mutex
+	cache   map[string][]string
+	timer   *time.Timer
+	pending string
+}
+
+func NewYouTubeSuggestions(onUpdate func(prefix string)) *YouTubeSuggestions {
+	return &YouTubeSuggestions{
+		client:   &http.Client{Timeout: 3 * time.Second},
+		onUpdate: onUpdate,
+		cache:    make(map[string][]string),
+	}
+}
+
+func (s *YouTubeSuggestions) Suggestions(prefix string) []string {
+	key := strings.ToLower(strings.TrimSpace(prefix))
+	if key == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if suggestions, ok := s.cache[key]; ok {
+		return suggestions
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.pending = key
+	s.timer = time.AfterFunc(suggestDelay, func() {
+		s.fetch(key)
+	})
+	return nil
+}
+
+func (s *YouTubeSuggestions) fetch(key string) {
+	s.mu.Lock()
+	current := s.pending == key
+	s.mu.Unlock()
+	if !current {
+		return
+	}
+
+	suggestions, err := s.lookup(key)
+	if err != nil {
+		log.Printf("Error fetching suggestions for %q: %v\n", key, err)
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.cache) >= suggestCacheLimit {
+		s.cache = make(map[string][]string)
+	}
+	s.cache[key] = suggestions
+	current = s.pending == key
+	s.mu.Unlock()
+
+	if current && s.onUpdate != nil {
+		s.onUpdate(key)
+	}
+}
+
+// lookup asks YouTube, which answers with ["query", ["suggestion", ...]]
+func (s *YouTubeSuggestions) lookup(query string) ([]string, error) {
+	params := url.Values{}
+	params.Set("client", "firefox")
+	params.Set("ds", "yt")
+	params.Set("q", query)
+	resp, err := s.client.Get("https://suggestqueries.google.com/complete/search?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var body []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if len(body) < 2 {
+		return nil, fmt.Errorf("unexpected response")
+	}
+	var suggestions []string
+	if err := json.Unmarshal(body[1], &suggestions); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
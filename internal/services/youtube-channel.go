@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// channelURLFromYtDlp picks the page of a channel from what yt-dlp reports,
+// preferring the @handle URL
+func channelURLFromYtDlp(uploaderURL, channelURL, channelID string) string {
+	switch {
+	case strings.Contains(uploaderURL, "/@"):
+		return uploaderURL
+	case channelURL != "":
+		return channelURL
+	case channelID != "":
+		return "https://www.youtube.com/channel/" + channelID
+	}
+	return uploaderURL
+}
+
+// ResolveChannelURL returns the page of the channel that uploaded video,
+// asking yt-dlp about the video if it wasn't recorded with it
+func ResolveChannelURL(ctx context.Context, video models.Video) (string, error) {
+	if video.ChannelURL != "" {
+		return video.ChannelURL, nil
+	}
+
+	args := []string{"-j", "--skip-download", "--no-playlist", "--no-warnings", video.ID}
+	var url string
+	err := streamYtDlpJSON(ctx, args, func(decoder *json.Decoder) error {
+		var item models.YtDlpVideoResponse
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		url = channelURLFromYtDlp(item.UploaderURL, item.ChannelURL, item.ChannelID)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if url == "" {
+		return "", fmt.Errorf("no channel found for %q", video.Title)
+	}
+	return url, nil
+}
+
+// GetChannelYtDlp returns the name, handle, subscriber count and description
+// of the channel at url
+func GetChannelYtDlp(ctx context.Context, url string) (models.Channel, error) {
+	args := []string{
+		"--flat-playlist",
+		"--no-warnings",
+		"-J",
+		"-I", "1",
+		channelTabURL(url, "videos"),
+	}
+	cmd := exec.CommandContext(ctx, getYtDlpPath(), args...)
+	stdout, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			log.Printf("Command failed with stderr: %s\n", string(exitErr.Stderr))
+		}
+		log.Printf("Error running command: %v\n", err)
+		return models.Channel{}, err
+	}
+
+	var response models.YtDlpChannelResponse
+	if err := json.Unmarshal(stdout, &response); err != nil {
+		return models.Channel{}, err
+	}
+	channel := models.Channel{
+		ID:          response.ChannelID,
+		Name:        response.Channel,
+		URL:         channelURLFromYtDlp(response.UploaderURL, response.ChannelURL, response.ChannelID),
+		Handle:      response.UploaderID,
+		Description: response.Description,
+		Subscribers: response.Followers,
+	}
+	if channel.URL == "" {
+		channel.URL = url
+	}
+	return channel, nil
+}
+
+// StreamChannelVideosYtDlp passes count uploads of a channel, starting at
+// the 1-based position start, to onVideo as soon as yt-dlp prints them. The
+// uploads are the newest first, or the most viewed first if popular is set.
+func StreamChannelVideosYtDlp(ctx context.Context, channel models.Channel, popular bool, start, count int, onVideo func(models.Video)) error {
+	url := channelTabURL(channel.URL, "videos")
+	if popular {
+		// The videos tab can't be sorted from the command line, but YouTube
+		// keeps the popular uploads of every channel as a playlist
+		id, err := ChannelPlaylistID(channel, true)
+		if err != nil {
+			return err
+		}
+		url = playlistURL(id)
+	}
+
+	args := []string{
+		"--flat-playlist",
+		"--no-warnings",
+		"-j",
+		"-I", fmt.Sprintf("%d:%d", start, start+count-1),
+		url,
+	}
+	return streamYtDlp(ctx, args, func(video models.Video) {
+		// Entries of a channel's tabs don't repeat the channel
+		if video.Channel == "" {
+			video.Channel = channel.Name
+		}
+		if video.ChannelURL == "" {
+			video.ChannelURL = channel.URL
+		}
+		onVideo(video)
+	})
+}
+
+// StreamChannelPlaylistsYtDlp passes count playlists of a channel, starting
+// at the 1-based position start, to onPlaylist as soon as yt-dlp prints them
+func StreamChannelPlaylistsYtDlp(ctx context.Context, channel models.Channel, start, count int, onPlaylist func(models.ChannelPlaylist)) error {
+	args := []string{
+		"--flat-playlist",
+		"--no-warnings",
+		"-j",
+		"-I", fmt.Sprintf("%d:%d", start, start+count-1),
+		channelTabURL(channel.URL, "playlists"),
+	}
+	return streamYtDlpJSON(ctx, args, func(decoder *json.Decoder) error {
+		var item models.YtDlpChannelPlaylistResponse
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		onPlaylist(models.ChannelPlaylist{ID: item.ID, Title: item.Title})
+		return nil
+	})
+}
+
+// ChannelPlaylistID returns the id of the playlist YouTube keeps of all the
+// uploads of a channel, newest first, or of its popular uploads
+func ChannelPlaylistID(channel models.Channel, popular bool) (string, error) {
+	id, ok := strings.CutPrefix(channel.ID, "UC")
+	if !ok {
+		return "", fmt.Errorf("the uploads of %q can't be listed without its channel id", channel.Name)
+	}
+	if popular {
+		return "UULP" + id, nil
+	}
+	return "UU" + id, nil
+}
+
+// channelTabURL returns the URL of a tab of the channel page at url, e.g.
+// https://www.youtube.com/@handle/videos
+func channelTabURL(url, tab string) string {
+	url = strings.TrimSuffix(url, "/")
+	for _, other := range []string{"/videos", "/playlists", "/featured", "/streams", "/shorts"} {
+		url = strings.TrimSuffix(url, other)
+	}
+	return url + "/" + tab
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/sangnt1552314/ytview/internal/models"
+)
+
+// PlaylistPageSize is the number of entries fetched per yt-dlp run
+const PlaylistPageSize = 100
+
+// MaxPlaylistItems stops the enumeration of very long playlists and of mixes,
+// which never end
+const MaxPlaylistItems = 1000
+
+// playlistIDPattern matches regular playlists (PL…), album playlists (OLAK5uy_…),
+// mixes (RD…) and the other list prefixes YouTube uses
+var playlistIDPattern = regexp.MustCompile(`^(PL|OLAK5uy_|RD|UU|FL|LL|OL)[A-Za-z0-9_-]{8,}$`)
+
+// ParsePlaylistID returns the playlist id from a YouTube playlist URL, a watch
+// URL with a list parameter or a bare playlist id
+func ParsePlaylistID(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	if playlistIDPattern.MatchString(input) {
+		return input, true
+	}
+
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host != "youtube.com" && host != "music.youtube.com" && host != "m.youtube.com" && host != "youtu.be" {
+		return "", false
+	}
+
+	id := u.Query().Get("list")
+	if !playlistIDPattern.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// playlistURL returns the URL yt-dlp should be given for a playlist id
+func playlistURL(id string) string {
+	// A mix is generated from its seed video and has to be opened as a watch page
+	if strings.HasPrefix(id, "RD") && len(id) == 13 {
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=%s", id[2:], id)
+	}
+	return "https://www.youtube.com/playlist?list=" + id
+}
+
+// GetPlaylistPageYtDlp returns the title of a playlist and up to count of its
+// entries starting at the 1-based index start. Cancelling ctx kills yt-dlp.
+func GetPlaylistPageYtDlp(ctx context.Context, id string, start, count int) (string, []models.Video, error) {
+	args := []string{
+		"--flat-playlist",
+		"--no-warnings",
+		"-J",
+		"-I",
+		fmt.Sprintf("%d:%d", start, start+count-1),
+		playlistURL(id),
+	}
+
+	var playlist models.YtDlpPlaylistResponse
+	err := streamYtDlpJSON(ctx, args, func(decoder *json.Decoder) error {
+		return decoder.Decode(&playlist)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	videos := make([]models.Video, 0, len(playlist.Entries))
+	for _, entry := range playlist.Entries {
+		video := videoFromYtDlp(entry)
+		if video.Channel == "" {
+			video.Channel = playlist.Channel
+		}
+		videos = append(videos, video)
+	}
+	return playlist.Title, videos, nil
+}
+
+// GetPlaylistYtDlp enumerates a whole playlist one page at a time, calling
+// onPage with each page as soon as it has been fetched. Cancelling ctx stops
+// it.
+func GetPlaylistYtDlp(ctx context.Context, id string, onPage func(title string, videos []models.Video)) error {
+	for start := 1; start <= MaxPlaylistItems; start += PlaylistPageSize {
+		title, videos, err := GetPlaylistPageYtDlp(ctx, id, start, PlaylistPageSize)
+		if err != nil {
+			return err
+		}
+		if len(videos) > 0 {
+			onPage(title, videos)
+		}
+		if len(videos) < PlaylistPageSize {
+			return nil
+		}
+	}
+	return nil
+}
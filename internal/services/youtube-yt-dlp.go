@@ -2,8 +2,10 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os/exec"
 	"runtime"
@@ -25,58 +27,40 @@ func GetYtDlpInfo(videoURL string) ([]byte, error) {
 	return cmd.Output()
 }
 
-func GetTrendingSongListYtDlp(maxResults int) ([]models.Video, error) {
-	ytDlpPath := getYtDlpPath()
+func videoFromYtDlp(item models.YtDlpVideoResponse) models.Video {
+	return models.Video{
+		ID:         item.ID,
+		Title:      item.Title,
+		Thumbnail:  item.Thumbnail,
+		Duration:   strconv.Itoa(int(item.Duration)),
+		Views:      strconv.Itoa(item.Views),
+		Channel:    item.Channel,
+		ChannelURL: channelURLFromYtDlp(item.UploaderURL, item.ChannelURL, item.ChannelID),
+	}
+}
 
+// StreamTrendingSongPageYtDlp passes count trending songs starting at the
+// 1-based position start to onVideo as soon as yt-dlp prints them.
+// Cancelling ctx kills yt-dlp.
+func StreamTrendingSongPageYtDlp(ctx context.Context, start, count int, onVideo func(models.Video)) error {
 	args := []string{
 		"--flat-playlist",
-		"-J",
+		"-j",
 		"-I",
-		fmt.Sprintf("1:%d", maxResults),
+		fmt.Sprintf("%d:%d", start, start+count-1),
 		"https://www.youtube.com/feed/trending?bp=4gINGgt5dG1hX2NoYXJ0cw%3D%3D",
 	}
-
-	cmd := exec.Command(ytDlpPath, args...)
-	stdout, err := cmd.Output()
-
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			log.Printf("Command failed with stderr: %s\n", string(exitErr.Stderr))
-		}
-		log.Printf("Error running command: %v\n", err)
-		return nil, err
-	}
-
-	var videos []models.Video
-	lines := bytes.Split(stdout, []byte("\n"))
-	for _, line := range lines {
-		if len(line) == 0 {
-			continue
-		}
-
-		var item models.YtDlpTrendingMusicResponse
-		err := json.Unmarshal(line, &item)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, entry := range item.Entries {
-			videos = append(videos, models.Video{
-				ID:        entry.ID,
-				Title:     entry.Title,
-				Thumbnail: entry.Thumbnail,
-				Duration:  strconv.Itoa(int(entry.Duration)),
-				Views:     strconv.Itoa(entry.Views),
-				Channel:   entry.Channel,
-			})
-		}
-	}
-
-	return videos, err
+	return streamYtDlp(ctx, args, onVideo)
 }
 
-func GetSongListYtDlp(query string, maxResults int) ([]models.Video, error) {
-	ytDlpPath := getYtDlpPath()
+// StreamSongPageYtDlp passes count search results starting at the 1-based
+// position start to onVideo as soon as yt-dlp prints them. Cancelling ctx
+// kills yt-dlp. A search can't start part way through, so yt-dlp is asked
+// for every result up to the end of the page and only extracts the ones on
+// it. Results that don't pass filters are left out, so a page may hold
+// fewer than count.
+func StreamSongPageYtDlp(ctx context.Context, query string, filters models.SearchFilters, start, count int, onVideo func(models.Video)) error {
+	end := start + count - 1
 
 	// query = strings.TrimSpace(query)
 	// query = strings.Replace(query, " ", "+", -1)
@@ -90,43 +74,64 @@ func GetSongListYtDlp(query string, maxResults int) ([]models.Video, error) {
 		"--quiet",
 		"-j",
 		"-S view_count",
-		fmt.Sprintf("ytsearch%d:%s", maxResults, query),
+		"-I", fmt.Sprintf("%d:%d", start, end),
 	}
+	args = append(args, ytDlpFilterArgs(filters)...)
+	args = append(args, fmt.Sprintf("ytsearch%d:%s", end, query))
+	return streamYtDlp(ctx, args, onVideo)
+}
 
-	cmd := exec.Command(ytDlpPath, args...)
-	stdout, err := cmd.Output()
+// streamYtDlp runs yt-dlp with args, which make it print one JSON object
+// per video, and decodes its output as it arrives
+func streamYtDlp(ctx context.Context, args []string, onVideo func(models.Video)) error {
+	return streamYtDlpJSON(ctx, args, func(decoder *json.Decoder) error {
+		var item models.YtDlpVideoResponse
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		onVideo(videoFromYtDlp(item))
+		return nil
+	})
+}
 
+// streamYtDlpJSON runs yt-dlp with args, which make it print one JSON
+// object per line, and calls decode for each object as it arrives
+func streamYtDlpJSON(ctx context.Context, args []string, decode func(decoder *json.Decoder) error) error {
+	cmd := exec.CommandContext(ctx, getYtDlpPath(), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			log.Printf("Command failed with stderr: %s\n", string(exitErr.Stderr))
-		}
+		return err
+	}
+	if err := cmd.Start(); err != nil {
 		log.Printf("Error running command: %v\n", err)
-		return nil, err
+		return err
 	}
 
-	var videos []models.Video
-	lines := bytes.Split(stdout, []byte("\n"))
-	for _, line := range lines {
-		if len(line) == 0 {
-			continue
-		}
-
-		var item models.YtDlpVideoResponse
-		err := json.Unmarshal(line, &item)
-		if err != nil {
-			return nil, err
+	decoder := json.NewDecoder(stdout)
+	var decodeErr error
+	for decoder.More() {
+		if err := decode(decoder); err != nil {
+			if err != io.EOF {
+				decodeErr = err
+				// Let yt-dlp finish writing so that it can exit
+				io.Copy(io.Discard, stdout)
+			}
+			break
 		}
-		videos = append(videos, models.Video{
-			ID:        item.ID,
-			Title:     item.Title,
-			Thumbnail: item.Thumbnail,
-			Duration:  strconv.Itoa(int(item.Duration)),
-			Views:     strconv.Itoa(item.Views),
-			Channel:   item.Channel,
-		})
 	}
 
-	return videos, nil
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log.Printf("Command failed with stderr: %s\n", stderr.String())
+		log.Printf("Error running command: %v\n", err)
+		return err
+	}
+	return decodeErr
 }
 
 func GetVideoAudioUrlYtDlp(videoId string) (string, error) {
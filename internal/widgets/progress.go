@@ -0,0 +1,107 @@
+package widgets
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sangnt1552314/ytview/internal/services"
+)
+
+// ProgressBar shows the elapsed and total time of a track and lets the user
+// seek by clicking on the bar
+type ProgressBar struct {
+	*tview.Box
+
+	position time.Duration
+	duration time.Duration
+	color    tcell.Color
+
+	// The position of the bar itself from the last draw, used for mouse clicks
+	barX, barWidth int
+
+	seek func(position time.Duration)
+}
+
+func NewProgressBar() *ProgressBar {
+	return &ProgressBar{
+		Box:   tview.NewBox(),
+		color: tcell.ColorYellow,
+	}
+}
+
+// SetProgress sets the elapsed and total time. A zero duration means the
+// length is unknown, e.g. for live streams.
+func (p *ProgressBar) SetProgress(position, duration time.Duration) *ProgressBar {
+	p.position = position
+	p.duration = duration
+	return p
+}
+
+func (p *ProgressBar) SetColor(color tcell.Color) *ProgressBar {
+	p.color = color
+	return p
+}
+
+// SetSeekFunc sets the function called with the position the user clicked on
+func (p *ProgressBar) SetSeekFunc(handler func(position time.Duration)) *ProgressBar {
+	p.seek = handler
+	return p
+}
+
+func (p *ProgressBar) Draw(screen tcell.Screen) {
+	p.Box.DrawForSubclass(screen, p)
+
+	x, y, width, height := p.GetInnerRect()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	y += height / 2
+
+	elapsed := services.FormatDuration(p.position)
+	total := "--:--"
+	if p.duration > 0 {
+		total = services.FormatDuration(p.duration)
+	}
+
+	p.barX = x + len(elapsed) + 1
+	p.barWidth = width - len(elapsed) - len(total) - 2
+	if p.barWidth < 1 {
+		// Not enough room for a bar, just show the times
+		tview.Print(screen, elapsed+" / "+total, x, y, width, tview.AlignCenter, p.color)
+		p.barWidth = 0
+		return
+	}
+
+	filled := 0
+	if p.duration > 0 {
+		filled = int(float64(p.barWidth) * float64(p.position) / float64(p.duration))
+		filled = max(0, min(filled, p.barWidth))
+	}
+
+	tview.Print(screen, elapsed, x, y, len(elapsed), tview.AlignLeft, p.color)
+	tview.Print(screen, strings.Repeat("━", filled), p.barX, y, filled, tview.AlignLeft, p.color)
+	tview.Print(screen, strings.Repeat("─", p.barWidth-filled), p.barX+filled, y, p.barWidth-filled, tview.AlignLeft, tcell.ColorGray)
+	tview.Print(screen, total, p.barX+p.barWidth+1, y, len(total), tview.AlignLeft, p.color)
+}
+
+func (p *ProgressBar) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return p.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		if !p.InRect(event.Position()) {
+			return false, nil
+		}
+		if action != tview.MouseLeftClick {
+			return false, nil
+		}
+
+		mouseX, _ := event.Position()
+		if p.seek == nil || p.duration <= 0 || p.barWidth <= 0 || mouseX < p.barX || mouseX >= p.barX+p.barWidth {
+			return true, nil
+		}
+
+		fraction := float64(mouseX-p.barX) / float64(p.barWidth)
+		p.seek(time.Duration(fraction * float64(p.duration)).Round(time.Second))
+		return true, nil
+	})
+}